@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"strconv"
@@ -97,8 +99,10 @@ func blinkNTimes(stones []int, rules []Rule, n int) []int {
 	return stones
 }
 
+// rules are the rules of the puzzle, unless read from a file with -rules, see rules.go
+var rules = []Rule{rule1, rule2, rule3}
+
 func answer1() int {
-	rules := []Rule{rule1, rule2, rule3}
 	stones := readInput()
 	rounds := 25
 	return len(blinkNTimes(stones, rules, rounds))
@@ -142,14 +146,12 @@ func (wip WorkInProgress) add(d DigitInfo) {
 	}
 }
 
-func answer2() int {
-	rules := []Rule{rule1, rule2, rule3}
-	stones := readInput()
+// countStones returns the number of stones after the given rounds of blinks
+func countStones(stones []int, rules []Rule, rounds int) int {
 	res := 0
-	rounds := 75
 	wip := make(WorkInProgress)
 	for _, s := range stones {
-		wip[[2]int{s, 0}] = DigitInfo{s, 0, 1}
+		wip.add(DigitInfo{s, 0, 1})
 	}
 	for len(wip) > 0 {
 		dInfo := wip.pop()
@@ -165,6 +167,10 @@ func answer2() int {
 	return res
 }
 
+func answer2() int {
+	return countStones(readInput(), rules, 75)
+}
+
 // -----------------------------------------------------------------------
 
 var correctAnswers = map[int]int{
@@ -186,15 +192,38 @@ func printAndTest(question int) {
 	println(answer)
 }
 
+var (
+	rulesFlag  = flag.String("rules", "", "read the rules from this file instead of using the puzzle ones")
+	blinksFlag = flag.Int("blinks", 0, "print the number of stones after n blinks instead of the answers")
+)
+
 func main() {
+	flag.Parse()
+	if *rulesFlag != "" {
+		file, err := os.Open(*rulesFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		rules, err = parseRules(file)
+		file.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		correctAnswers = map[int]int{}
+	}
+	if *blinksFlag > 0 {
+		fmt.Println(countStones(readInput(), rules, *blinksFlag))
+		return
+	}
+	args := flag.Args()
 	// if no argument, run all answers, otherwise only part 1 or 2
-	if len(os.Args) == 1 || os.Args[1] == "1" {
+	if len(args) == 0 || args[0] == "1" {
 		printAndTest(1)
 	}
-	if len(os.Args) == 1 || os.Args[1] == "2" {
+	if len(args) == 0 || args[0] == "2" {
 		printAndTest(2)
 	}
-	if len(os.Args) > 1 && os.Args[1] != "1" && os.Args[1] != "2" {
+	if len(args) > 0 && args[0] != "1" && args[0] != "2" {
 		println("Give 1 or 2 as argument, or no argument at all")
 	}
 }
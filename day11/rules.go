@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Rules can also be read from a text file, one per line in the order they are
+// tried, as "<conditions> -> <action>". Empty lines and lines starting with '#'
+// are ignored. The conditions are joined by "and" and can be:
+// - always
+// - value <op> N, with <op> one of == != < <= > >=
+// - digits <op> N, on the number of digits of the value
+// - digits even, digits odd
+// The actions are:
+// - replace N1 N2 ..., with one new stone for each number
+// - multiply N
+// - split, in two stones with the first and the second half of the digits, the
+//   first half being the shorter one if the number of digits is odd. Stones with
+//   a single digit cannot be split, so split needs a condition such as digits >= 2
+// A stone no rule applies to disappears. The rules of the puzzle are:
+//
+//	value == 0 -> replace 1
+//	digits even -> split
+//	always -> multiply 2024
+
+type condition func(n int) bool
+
+func numDigits(n int) int {
+	return len(strconv.Itoa(n))
+}
+
+func parseCondition(s string) (condition, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 1 && fields[0] == "always" {
+		return func(int) bool { return true }, nil
+	}
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid condition %q", s)
+	}
+	var subject func(n int) int
+	switch fields[0] {
+	case "value":
+		subject = func(n int) int { return n }
+	case "digits":
+		subject = numDigits
+	default:
+		return nil, fmt.Errorf("condition %q: unknown subject %q", s, fields[0])
+	}
+	if len(fields) == 2 && fields[0] == "digits" && (fields[1] == "even" || fields[1] == "odd") {
+		remainder := 0
+		if fields[1] == "odd" {
+			remainder = 1
+		}
+		return func(n int) bool { return numDigits(n)%2 == remainder }, nil
+	}
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("invalid condition %q", s)
+	}
+	value, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: %w", s, err)
+	}
+	compare, ok := map[string]func(a, b int) bool{
+		"==": func(a, b int) bool { return a == b },
+		"!=": func(a, b int) bool { return a != b },
+		"<":  func(a, b int) bool { return a < b },
+		"<=": func(a, b int) bool { return a <= b },
+		">":  func(a, b int) bool { return a > b },
+		">=": func(a, b int) bool { return a >= b },
+	}[fields[1]]
+	if !ok {
+		return nil, fmt.Errorf("condition %q: unknown operator %q", s, fields[1])
+	}
+	return func(n int) bool { return compare(subject(n), value) }, nil
+}
+
+func splitDigits(n int) []int {
+	nStr := strconv.Itoa(n)
+	if len(nStr) < 2 {
+		log.Fatalf("stone %d has a single digit and cannot be split", n)
+	}
+	half := len(nStr) / 2
+	// both halves are non-empty runs of digits, so they cannot fail to convert
+	left, _ := strconv.Atoi(nStr[:half])
+	right, _ := strconv.Atoi(nStr[half:])
+	return []int{left, right}
+}
+
+func parseAction(s string) (func(n int) []int, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing action")
+	}
+	var values []int
+	for _, field := range fields[1:] {
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("action %q: %w", s, err)
+		}
+		if value < 0 {
+			return nil, fmt.Errorf("action %q: negative number %d", s, value)
+		}
+		values = append(values, value)
+	}
+	switch {
+	case fields[0] == "replace" && len(values) > 0:
+		return func(int) []int { return append([]int{}, values...) }, nil
+	case fields[0] == "multiply" && len(values) == 1:
+		factor := values[0]
+		return func(n int) []int {
+			if factor != 0 && n > math.MaxInt/factor {
+				log.Fatalf("stone %d multiplied by %d overflows an int", n, factor)
+			}
+			return []int{n * factor}
+		}, nil
+	case fields[0] == "split" && len(values) == 0:
+		return splitDigits, nil
+	}
+	return nil, fmt.Errorf("invalid action %q", s)
+}
+
+// parseRules reads a rules file
+func parseRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		conditionsText, actionText, ok := strings.Cut(line, "->")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing -> in %q", n, line)
+		}
+		var conditions []condition
+		for _, s := range strings.Split(conditionsText, " and ") {
+			c, err := parseCondition(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			conditions = append(conditions, c)
+		}
+		action, err := parseAction(actionText)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		rules = append(rules, func(n int) ([]int, bool) {
+			for _, c := range conditions {
+				if !c(n) {
+					return []int{n}, false
+				}
+			}
+			return action(n), true
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
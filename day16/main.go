@@ -2,6 +2,8 @@ package main
 
 import (
 	"container/heap"
+	"flag"
+	"fmt"
 	"io"
 	"log"
 	"math"
@@ -12,6 +14,7 @@ import (
 // We have a maze with walls '#' and open spaces '.', start 'S' and end 'E'.
 // We start facing east and need to find the lowest cost path to the end.
 // A move forward costs 1, a 90-degree turn costs 1000.
+// (the costs can be changed with the -move, -turn and -uturn flags)
 
 type Pos struct {
 	x, y int
@@ -69,12 +72,6 @@ type PathState struct {
 	dir Pos
 }
 
-type Path struct {
-	pos     Pos
-	dir     Pos
-	visited map[Pos]bool
-}
-
 var (
 	north = Pos{0, -1}
 	east  = Pos{1, 0}
@@ -82,115 +79,161 @@ var (
 	west  = Pos{-1, 0}
 )
 
-type Paths map[PathState]map[Pos]bool // pathState -> visited
+// Costs are the costs of moving one tile forward and of turning before moving
+type Costs struct {
+	move  int
+	turn  int // 90-degree turn
+	uturn int // 180-degree turn
+}
 
+// the puzzle costs, with a 180-degree turn charged as a single turn
+var defaultCosts = Costs{move: 1, turn: 1000, uturn: 1000}
+
+// stepCost returns the cost of moving from a state to the next one
+func (c Costs) stepCost(from, to PathState) int {
+	switch to.dir {
+	case from.dir:
+		return c.move
+	case Pos{-from.dir.x, -from.dir.y}:
+		return c.move + c.uturn
+	}
+	return c.move + c.turn
+}
+
+// PathManager runs a Dijkstra search over the (pos, dir) states of the maze,
+// recording for each state all the predecessors on one of its lowest cost paths.
+// If costToEnd is set, it is used as the heuristic of an A* search instead.
 type PathManager struct {
 	world     *World
+	costs     Costs
+	start     PathState
 	costsHeap PriorityQueue
-	paths     map[int]Paths     // cost -> (pathState -> visited)
-	costs     map[PathState]int // pathState -> cost
+	frontier  map[int][]PathState       // cost + heuristic -> states
+	best      map[PathState]int         // pathState -> lowest known cost
+	preds     map[PathState][]PathState // pathState -> optimal predecessors
+	settled   map[PathState]bool
+
+	// states and moves the search must avoid, used to find alternative routes
+	blockedStates map[PathState]bool
+	blockedMoves  map[[2]PathState]bool
+	costToEnd     map[PathState]int
 }
 
-func NewPathManager(w *World) *PathManager {
-	pq := PriorityQueue{0}
-	heap.Init(&pq)
-	initialPathState := PathState{w.start, east}
-	paths := map[int]Paths{0: {PathState{w.start, east}: map[Pos]bool{}}}
-	costs := map[PathState]int{initialPathState: 0}
-	return &PathManager{w, pq, paths, costs}
+func NewPathManager(w *World, costs Costs, start PathState) *PathManager {
+	return &PathManager{
+		world:     w,
+		costs:     costs,
+		start:     start,
+		costsHeap: PriorityQueue{},
+		frontier:  map[int][]PathState{},
+		best:      map[PathState]int{},
+		preds:     map[PathState][]PathState{},
+		settled:   map[PathState]bool{},
+	}
 }
 
-func (pm *PathManager) add(path Path, cost int) {
-	pathState := PathState{path.pos, path.dir}
-	if currentCost, ok := pm.costs[pathState]; ok {
+// heuristic returns a lower bound of the cost from pathState to the end, and false
+// if the end cannot be reached from it
+func (pm *PathManager) heuristic(pathState PathState) (int, bool) {
+	if pm.costToEnd == nil {
+		return 0, true
+	}
+	h, ok := pm.costToEnd[pathState]
+	return h, ok
+}
+
+// add records that pathState can be reached from pred with the given cost
+func (pm *PathManager) add(pred, pathState PathState, cost int) {
+	h, ok := pm.heuristic(pathState)
+	if !ok {
+		return
+	}
+	if currentCost, ok := pm.best[pathState]; ok {
 		if cost > currentCost {
 			return
 		}
 		if cost == currentCost {
-			// save the visited tiles for part 2
-			for pos := range path.visited {
-				pm.paths[currentCost][pathState][pos] = true
-			}
+			pm.preds[pathState] = append(pm.preds[pathState], pred)
 			return
 		}
-		// we need to remove the old path
-		delete(pm.costs, pathState)
-		delete(pm.paths[currentCost], pathState)
-		if len(pm.paths[currentCost]) == 0 {
-			delete(pm.paths, currentCost)
-			heap.Remove(&pm.costsHeap, pm.costsHeap.FindIndex(currentCost))
-		}
-	}
-	_, ok := pm.paths[cost]
-	if !ok {
-		heap.Push(&pm.costsHeap, cost)
-		pm.paths[cost] = map[PathState]map[Pos]bool{}
+		// the old entry in the frontier becomes stale and is skipped by pop
 	}
-	pm.paths[cost][pathState] = path.visited
-	pm.costs[pathState] = cost
+	pm.push(pathState, cost, h)
+	pm.preds[pathState] = []PathState{pred}
 }
 
-func (pm *PathManager) pop() (Path, int, bool) {
-	cost, ok := pm.costsHeap.Peek()
-	if !ok {
-		return Path{}, cost, false
-	}
-	if len(pm.paths[cost]) == 0 {
-		panic("No path found for existing cost")
-	}
-	var pathState PathState
-	var visited map[Pos]bool
-	for k, v := range pm.paths[cost] {
-		pathState = k
-		visited = v
-		break
-	}
-	path := Path{pathState.pos, pathState.dir, visited}
-	delete(pm.paths[cost], pathState)
-	if len(pm.paths[cost]) == 0 {
-		delete(pm.paths, cost)
-		heap.Pop(&pm.costsHeap)
+func (pm *PathManager) push(pathState PathState, cost, h int) {
+	if _, ok := pm.frontier[cost+h]; !ok {
+		heap.Push(&pm.costsHeap, cost+h)
 	}
-	delete(pm.costs, PathState{path.pos, path.dir})
-	return path, cost, true
+	pm.frontier[cost+h] = append(pm.frontier[cost+h], pathState)
+	pm.best[pathState] = cost
 }
 
-const (
-	moveCost = 1
-	turnCost = 1000
-)
-
-func clone(visited map[Pos]bool) map[Pos]bool {
-	newVisited := map[Pos]bool{}
-	for k, v := range visited {
-		newVisited[k] = v
+// pop returns the unsettled state with the lowest cost plus heuristic and settles
+// it, returning its cost and its cost plus heuristic
+func (pm *PathManager) pop() (PathState, int, int, bool) {
+	for {
+		priority, ok := pm.costsHeap.Peek()
+		if !ok {
+			return PathState{}, 0, 0, false
+		}
+		states := pm.frontier[priority]
+		pathState := states[len(states)-1]
+		if len(states) == 1 {
+			delete(pm.frontier, priority)
+			heap.Pop(&pm.costsHeap)
+		} else {
+			pm.frontier[priority] = states[:len(states)-1]
+		}
+		h, _ := pm.heuristic(pathState)
+		cost := pm.best[pathState]
+		if pm.settled[pathState] || cost+h != priority {
+			continue
+		}
+		pm.settled[pathState] = true
+		return pathState, cost, priority, true
 	}
-	return newVisited
 }
 
-func answer1() int {
-	w := readInput()
-	pm := NewPathManager(&w)
-	for len(pm.paths) > 0 {
-		path, cost, _ := pm.pop()
-		if path.pos == w.end {
-			return cost
+// search runs the search until all the states whose cost (plus heuristic) is not
+// greater than the lowest cost to the end are settled, and returns that cost
+func (pm *PathManager) search() (int, bool) {
+	w := pm.world
+	bestCost := math.MaxInt
+	if h, ok := pm.heuristic(pm.start); ok {
+		pm.push(pm.start, 0, h)
+	}
+	for {
+		pathState, cost, priority, ok := pm.pop()
+		if !ok || priority > bestCost {
+			break
+		}
+		if pathState.pos == w.end {
+			bestCost = cost
+			continue
 		}
-		path.visited[path.pos] = true
 		for _, dir := range []Pos{north, east, south, west} {
-			newPos := Pos{path.pos.x + dir.x, path.pos.y + dir.y}
-			newCost := cost + moveCost
-			if dir != path.dir {
-				newCost += turnCost
-			}
-			if !(w.walls[newPos]) && !path.visited[newPos] {
-				newPath := Path{newPos, dir, clone(path.visited)}
-				pm.add(newPath, newCost)
+			newPos := Pos{pathState.pos.x + dir.x, pathState.pos.y + dir.y}
+			newPathState := PathState{newPos, dir}
+			if w.walls[newPos] || pm.blockedStates[newPathState] ||
+				pm.blockedMoves[[2]PathState{pathState, newPathState}] {
+				continue
 			}
+			pm.add(pathState, newPathState, cost+pm.costs.stepCost(pathState, newPathState))
 		}
 	}
-	log.Fatal("No path found")
-	return -1
+	return bestCost, bestCost != math.MaxInt
+}
+
+func answer1() int {
+	w := readInput()
+	pm := NewPathManager(&w, costsFromFlags(), PathState{w.start, east})
+	bestCost, ok := pm.search()
+	if !ok {
+		log.Fatal("No path found")
+	}
+	return bestCost
 }
 
 // -----------------------------------------------------------------------
@@ -199,33 +242,41 @@ func answer1() int {
 // now find all the tiles that are part of at least one of the optimal
 // paths (i.e., lowest cost) from start to end
 
-func answer2() int {
-	w := readInput()
-	pm := NewPathManager(&w)
-	bestCost := math.MaxInt
-	bestTiles := map[Pos]bool{}
-	for len(pm.paths) > 0 {
-		path, cost, _ := pm.pop()
-		path.visited[path.pos] = true
-		if path.pos == w.end {
-			bestCost = cost
-			for pos := range path.visited {
-				bestTiles[pos] = true
-			}
+// bestTiles walks back the optimal predecessors from the end states with the lowest
+// cost and returns all the tiles found along the way
+func (pm *PathManager) bestTiles(bestCost int) map[Pos]bool {
+	tiles := map[Pos]bool{}
+	seen := map[PathState]bool{}
+	var stack []PathState
+	for _, dir := range []Pos{north, east, south, west} {
+		endState := PathState{pm.world.end, dir}
+		if cost, ok := pm.best[endState]; ok && cost == bestCost {
+			stack = append(stack, endState)
+			seen[endState] = true
 		}
-		for _, dir := range []Pos{north, east, south, west} {
-			newPos := Pos{path.pos.x + dir.x, path.pos.y + dir.y}
-			newCost := cost + moveCost
-			if dir != path.dir {
-				newCost += turnCost
-			}
-			if !(w.walls[newPos]) && !path.visited[newPos] && !(newCost > bestCost) {
-				newPath := Path{newPos, dir, clone(path.visited)}
-				pm.add(newPath, newCost)
+	}
+	for len(stack) > 0 {
+		pathState := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		tiles[pathState.pos] = true
+		for _, pred := range pm.preds[pathState] {
+			if !seen[pred] {
+				seen[pred] = true
+				stack = append(stack, pred)
 			}
 		}
 	}
-	return len(bestTiles)
+	return tiles
+}
+
+func answer2() int {
+	w := readInput()
+	pm := NewPathManager(&w, costsFromFlags(), PathState{w.start, east})
+	bestCost, ok := pm.search()
+	if !ok {
+		log.Fatal("No path found")
+	}
+	return len(pm.bestTiles(bestCost))
 }
 
 // -----------------------------------------------------------------------
@@ -249,15 +300,44 @@ func printAndTest(question int) {
 	println(answer)
 }
 
+var (
+	moveFlag   = flag.Int("move", defaultCosts.move, "cost of moving one tile forward")
+	turnFlag   = flag.Int("turn", defaultCosts.turn, "cost of a 90-degree turn")
+	uturnFlag  = flag.Int("uturn", defaultCosts.uturn, "cost of a 180-degree turn")
+	routesFlag = flag.Int("routes", 0, "print the k cheapest distinct routes instead of the answers")
+)
+
+func costsFromFlags() Costs {
+	costs := Costs{move: *moveFlag, turn: *turnFlag, uturn: *uturnFlag}
+	// with a free move there could be zero cost cycles in the predecessors
+	if costs.move <= 0 || costs.turn < 0 || costs.uturn < 0 {
+		log.Fatal("The move cost must be positive and the turn costs not negative")
+	}
+	return costs
+}
+
 func main() {
+	flag.Parse()
+	if costsFromFlags() != defaultCosts {
+		// the correct answers are only known for the puzzle costs
+		correctAnswers = map[int]int{}
+	}
+	if *routesFlag > 0 {
+		w := readInput()
+		for _, route := range kCheapestRoutes(&w, costsFromFlags(), *routesFlag) {
+			fmt.Println(route)
+		}
+		return
+	}
+	args := flag.Args()
 	// if no argument, run all answers, otherwise only part 1 or 2
-	if len(os.Args) == 1 || os.Args[1] == "1" {
+	if len(args) == 0 || args[0] == "1" {
 		printAndTest(1)
 	}
-	if len(os.Args) == 1 || os.Args[1] == "2" {
+	if len(args) == 0 || args[0] == "2" {
 		printAndTest(2)
 	}
-	if len(os.Args) > 1 && os.Args[1] != "1" && os.Args[1] != "2" {
+	if len(args) > 0 && args[0] != "1" && args[0] != "2" {
 		println("Give 1 or 2 as argument, or no argument at all")
 	}
 }
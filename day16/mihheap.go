@@ -28,14 +28,3 @@ func (h PriorityQueue) Peek() (int, bool) {
 	}
 	return h[0], true
 }
-
-// FindIndex returns the index of the first occurrence of val in the heap.
-// It panics if val is not found.
-func (h PriorityQueue) FindIndex(val int) int {
-	for i, v := range h {
-		if v == val {
-			return i
-		}
-	}
-	panic("Value not found")
-}
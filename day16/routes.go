@@ -0,0 +1,181 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Route is a path from the start to the end of the maze with its cost
+type Route struct {
+	cost   int
+	states []PathState // states[0] is the start state, then one state per move
+}
+
+// moves returns the direction of each move of the route
+func (r Route) moves() []Pos {
+	moves := make([]Pos, 0, len(r.states)-1)
+	for _, s := range r.states[1:] {
+		moves = append(moves, s.dir)
+	}
+	return moves
+}
+
+var dirNames = map[Pos]string{north: "N", east: "E", south: "S", west: "W"}
+
+// String returns the cost and the moves of the route, grouping consecutive moves
+// in the same direction, e.g. "7036: N2 E4 ..."
+func (r Route) String() string {
+	var groups []string
+	moves := r.moves()
+	for i := 0; i < len(moves); {
+		j := i
+		for j < len(moves) && moves[j] == moves[i] {
+			j++
+		}
+		groups = append(groups, fmt.Sprintf("%s%d", dirNames[moves[i]], j-i))
+		i = j
+	}
+	return fmt.Sprintf("%d: %s", r.cost, strings.Join(groups, " "))
+}
+
+// routeCost returns the cost of moving along the given states
+func routeCost(costs Costs, states []PathState) int {
+	cost := 0
+	for i := 1; i < len(states); i++ {
+		cost += costs.stepCost(states[i-1], states[i])
+	}
+	return cost
+}
+
+// costsToEnd returns the lowest cost from each state to the end of the maze, running
+// a Dijkstra search backwards from the end states
+func costsToEnd(w *World, costs Costs) map[PathState]int {
+	best := map[PathState]int{}
+	frontier := map[int][]PathState{}
+	costsHeap := PriorityQueue{0}
+	for _, dir := range []Pos{north, east, south, west} {
+		endState := PathState{w.end, dir}
+		best[endState] = 0
+		frontier[0] = append(frontier[0], endState)
+	}
+	settled := map[PathState]bool{}
+	for len(costsHeap) > 0 {
+		cost := heap.Pop(&costsHeap).(int)
+		for _, pathState := range frontier[cost] {
+			if settled[pathState] || best[pathState] != cost {
+				continue
+			}
+			settled[pathState] = true
+			// the states from which we move to pathState
+			prevPos := Pos{pathState.pos.x - pathState.dir.x, pathState.pos.y - pathState.dir.y}
+			if w.walls[prevPos] {
+				continue
+			}
+			for _, dir := range []Pos{north, east, south, west} {
+				prevState := PathState{prevPos, dir}
+				newCost := cost + costs.stepCost(prevState, pathState)
+				if currentCost, ok := best[prevState]; ok && currentCost <= newCost {
+					continue
+				}
+				if _, ok := frontier[newCost]; !ok {
+					heap.Push(&costsHeap, newCost)
+				}
+				frontier[newCost] = append(frontier[newCost], prevState)
+				best[prevState] = newCost
+			}
+		}
+		delete(frontier, cost)
+	}
+	return best
+}
+
+// shortestRoute returns one of the lowest cost routes from start to the end of the
+// maze which avoids the blocked states and moves, using the costs to the end of
+// the unblocked maze as the A* heuristic
+func shortestRoute(w *World, costs Costs, start PathState, costToEnd map[PathState]int,
+	blockedStates map[PathState]bool, blockedMoves map[[2]PathState]bool) (Route, bool) {
+
+	pm := NewPathManager(w, costs, start)
+	pm.blockedStates, pm.blockedMoves = blockedStates, blockedMoves
+	pm.costToEnd = costToEnd
+	bestCost, ok := pm.search()
+	if !ok {
+		return Route{}, false
+	}
+	var state PathState
+	for _, dir := range []Pos{north, east, south, west} {
+		state = PathState{w.end, dir}
+		if cost, ok := pm.best[state]; ok && cost == bestCost {
+			break
+		}
+	}
+	states := []PathState{state}
+	for state != start {
+		state = pm.preds[state][0]
+		states = append(states, state)
+	}
+	slices.Reverse(states)
+	return Route{bestCost, states}, true
+}
+
+// kCheapestRoutes returns up to k distinct routes from start to end in order of
+// increasing cost using Yen's algorithm: each new route is the cheapest among the
+// deviations of the last route found, which follow it up to a spur state and then
+// take the cheapest way to the end avoiding the moves of the routes already found
+func kCheapestRoutes(w *World, costs Costs, k int) []Route {
+	costToEnd := costsToEnd(w, costs)
+	first, ok := shortestRoute(w, costs, PathState{w.start, east}, costToEnd, nil, nil)
+	if !ok {
+		return nil
+	}
+	routes := []Route{first}
+	var candidates []Route
+	isKnown := func(r Route) bool {
+		sameStates := func(other Route) bool { return slices.Equal(r.states, other.states) }
+		return slices.ContainsFunc(routes, sameStates) || slices.ContainsFunc(candidates, sameStates)
+	}
+
+	for len(routes) < k {
+		last := routes[len(routes)-1]
+		for i := 0; i < len(last.states)-1; i++ {
+			spur := last.states[i]
+			root := last.states[:i+1]
+			blockedMoves := map[[2]PathState]bool{}
+			for _, r := range routes {
+				if len(r.states) > i+1 && slices.Equal(r.states[:i+1], root) {
+					blockedMoves[[2]PathState{r.states[i], r.states[i+1]}] = true
+				}
+			}
+			// the root states cannot be visited again to keep the route loopless
+			blockedStates := map[PathState]bool{}
+			for _, s := range root[:i] {
+				blockedStates[s] = true
+			}
+			spurRoute, ok := shortestRoute(w, costs, spur, costToEnd, blockedStates, blockedMoves)
+			if !ok {
+				continue
+			}
+			candidate := Route{
+				cost:   routeCost(costs, root) + spurRoute.cost,
+				states: append(slices.Clone(root[:i]), spurRoute.states...),
+			}
+			if !isKnown(candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		best := 0
+		for i, c := range candidates {
+			if c.cost < candidates[best].cost {
+				best = i
+			}
+		}
+		routes = append(routes, candidates[best])
+		candidates = slices.Delete(candidates, best, best+1)
+	}
+	return routes
+}
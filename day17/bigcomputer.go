@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BigComputer is a Computer whose registers are arbitrary precision integers, for
+// programs where A does not fit in 64 bits or where a division uses a power of 2
+// larger than 63
+type BigComputer struct {
+	A, B, C *big.Int
+	program []int
+	pc      int
+	output  []int
+}
+
+func initializeBigComputer(is InitialState) BigComputer {
+	register := func(bigValue *big.Int, value int) *big.Int {
+		if bigValue != nil {
+			return new(big.Int).Set(bigValue)
+		}
+		return big.NewInt(int64(value))
+	}
+	return BigComputer{
+		A:       register(is.bigA, is.A),
+		B:       register(is.bigB, is.B),
+		C:       register(is.bigC, is.C),
+		program: is.program,
+		output:  []int{},
+	}
+}
+
+func (c *BigComputer) combo(operand int) (*big.Int, error) {
+	if operand >= 0 && operand < 4 {
+		return big.NewInt(int64(operand)), nil
+	}
+	switch operand {
+	case 4:
+		return c.A, nil
+	case 5:
+		return c.B, nil
+	case 6:
+		return c.C, nil
+	case 7:
+		return nil, fmt.Errorf("reserved combo operand 7 at pc %d", c.pc)
+	}
+	return nil, fmt.Errorf("invalid combo operand %d at pc %d", operand, c.pc)
+}
+
+// divideA returns A divided by 2 to the power of exp, truncated
+func (c *BigComputer) divideA(exp *big.Int) (*big.Int, error) {
+	if exp.Sign() < 0 {
+		return nil, fmt.Errorf("negative power of 2 at pc %d", c.pc)
+	}
+	result := new(big.Int)
+	if !exp.IsInt64() || exp.Int64() > int64(c.A.BitLen()) {
+		return result, nil
+	}
+	// Quo truncates towards zero like the int division, unlike Rsh for negative A
+	return result.Quo(c.A, new(big.Int).Lsh(big.NewInt(1), uint(exp.Int64()))), nil
+}
+
+func (c *BigComputer) halted() bool {
+	return c.pc >= len(c.program)
+}
+
+func (c *BigComputer) step() error {
+	if c.pc < 0 {
+		return fmt.Errorf("invalid pc %d", c.pc)
+	}
+	if c.pc+1 >= len(c.program) {
+		return fmt.Errorf("missing operand at pc %d", c.pc)
+	}
+	instr := c.program[c.pc]
+	operand := c.program[c.pc+1]
+
+	var value *big.Int
+	switch instr {
+	case 0, 2, 5, 6, 7:
+		var err error
+		if value, err = c.combo(operand); err != nil {
+			return err
+		}
+	case 1, 3, 4:
+	default:
+		return fmt.Errorf("invalid instruction %d at pc %d", instr, c.pc)
+	}
+	if instr == 0 || instr == 6 || instr == 7 {
+		var err error
+		if value, err = c.divideA(value); err != nil {
+			return err
+		}
+	}
+
+	c.pc += 2
+	switch instr {
+	case 0:
+		c.A = value
+	case 1:
+		c.B = new(big.Int).Xor(c.B, big.NewInt(int64(operand)))
+	case 2:
+		c.B = big.NewInt(int64(mod8(value)))
+	case 3:
+		if c.A.Sign() != 0 {
+			c.pc = operand
+		}
+	case 4:
+		c.B = new(big.Int).Xor(c.B, c.C)
+	case 5:
+		c.output = append(c.output, mod8(value))
+	case 6:
+		c.B = value
+	case 7:
+		c.C = value
+	}
+	return nil
+}
+
+// mod8 returns x % 8 with the sign of x, like the int % operator
+func mod8(x *big.Int) int {
+	return int(new(big.Int).Rem(x, big.NewInt(8)).Int64())
+}
+
+func (c *BigComputer) run() (string, error) {
+	for !c.halted() {
+		if err := c.step(); err != nil {
+			return c.getOutput(), err
+		}
+	}
+	return c.getOutput(), nil
+}
+
+func (c *BigComputer) getOutput() string {
+	strOutputs := make([]string, len(c.output))
+	for i, o := range c.output {
+		strOutputs[i] = strconv.Itoa(o)
+	}
+	return strings.Join(strOutputs, ",")
+}
+
+// bigOutputForA is outputForA in big mode
+func bigOutputForA(program []int, A *big.Int) (int, error) {
+	c := BigComputer{A: A, B: new(big.Int), C: new(big.Int),
+		program: program[:len(program)-2]}
+	if _, err := c.run(); err != nil {
+		return 0, err
+	}
+	return c.output[0], nil
+}
+
+// findBigQuine is findQuine in big mode, for programs longer than 21 values
+// whose quine does not fit in an int
+func findBigQuine(program []int) (*big.Int, error) {
+	if err := checkLoopShape(program); err != nil {
+		return nil, fmt.Errorf("program is not a quine candidate: %w", err)
+	}
+	As := []*big.Int{new(big.Int)}
+	for i := len(program) - 1; i >= 0; i-- {
+		newAs := []*big.Int{}
+		for _, A := range As {
+			for remainder := int64(0); remainder < 8; remainder++ {
+				candidate := new(big.Int).Lsh(A, 3)
+				candidate.Add(candidate, big.NewInt(remainder))
+				output, err := bigOutputForA(program, candidate)
+				if err != nil {
+					return nil, err
+				}
+				if output == program[i] {
+					newAs = append(newAs, candidate)
+				}
+			}
+		}
+		As = newAs
+	}
+	slices.SortFunc(As, (*big.Int).Cmp)
+	for _, A := range As {
+		c := BigComputer{A: new(big.Int).Set(A), B: new(big.Int), C: new(big.Int),
+			program: program}
+		if _, err := c.run(); err == nil && slices.Equal(c.output, program) {
+			return A, nil
+		}
+	}
+	return nil, fmt.Errorf("no solution found")
+}
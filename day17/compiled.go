@@ -0,0 +1,150 @@
+package main
+
+import "fmt"
+
+// CompiledProgram is a program turned into a chain of closures, one per instruction
+// pointer, with combo operands and opcodes resolved once at compile time. It avoids
+// the decoding done by Computer.step and is meant for brute-force evaluation of the
+// same program over many initial registers.
+type CompiledProgram struct {
+	ops []compiledOp
+	// reused across runs to avoid allocating the output at each run
+	state compiledState
+}
+
+type compiledState struct {
+	A, B, C int
+	output  []int
+	err     error
+}
+
+// compiledOp executes an instruction and returns the next instruction pointer
+type compiledOp func(s *compiledState) int
+
+// compile returns the compiled program. Instructions that are invalid compile to an
+// op that stops the run with an error, so that they only fail if reached.
+func compile(program []int) *CompiledProgram {
+	halt := len(program)
+	ops := make([]compiledOp, len(program))
+	// a jump can land on an odd pc, so compile every position and not only even ones
+	for pc := range program {
+		ops[pc] = compileOp(program, pc, halt)
+	}
+	return &CompiledProgram{ops: ops}
+}
+
+func compileOp(program []int, pc, halt int) compiledOp {
+	fail := func(err error) compiledOp {
+		return func(s *compiledState) int {
+			s.err = err
+			return halt
+		}
+	}
+	if pc+1 >= len(program) {
+		return fail(fmt.Errorf("missing operand at pc %d", pc))
+	}
+	instr, operand, next := program[pc], program[pc+1], pc+2
+
+	var combo func(s *compiledState) int
+	switch {
+	case operand >= 0 && operand < 4:
+		combo = func(*compiledState) int { return operand }
+	case operand == 4:
+		combo = func(s *compiledState) int { return s.A }
+	case operand == 5:
+		combo = func(s *compiledState) int { return s.B }
+	case operand == 6:
+		combo = func(s *compiledState) int { return s.C }
+	case operand == 7:
+		combo = nil
+	}
+	if combo == nil && (instr == 0 || instr == 2 || instr == 5 || instr == 6 || instr == 7) {
+		return fail(fmt.Errorf("invalid combo operand %d at pc %d", operand, pc))
+	}
+	divideA := func(s *compiledState) (int, bool) {
+		exp := combo(s)
+		if exp < 0 {
+			s.err = fmt.Errorf("negative power of 2 at pc %d", pc)
+			return 0, false
+		}
+		c := Computer{A: s.A}
+		value, _ := c.divideA(exp)
+		return value, true
+	}
+
+	switch instr {
+	case 0:
+		// the most common form, adv 3, gets a dedicated op without the checks
+		if operand < 4 {
+			return func(s *compiledState) int {
+				s.A /= 1 << operand
+				return next
+			}
+		}
+		return func(s *compiledState) int {
+			value, ok := divideA(s)
+			if !ok {
+				return halt
+			}
+			s.A = value
+			return next
+		}
+	case 1:
+		return func(s *compiledState) int {
+			s.B ^= operand
+			return next
+		}
+	case 2:
+		return func(s *compiledState) int {
+			s.B = combo(s) % 8
+			return next
+		}
+	case 3:
+		return func(s *compiledState) int {
+			if s.A != 0 {
+				return operand
+			}
+			return next
+		}
+	case 4:
+		return func(s *compiledState) int {
+			s.B ^= s.C
+			return next
+		}
+	case 5:
+		return func(s *compiledState) int {
+			s.output = append(s.output, combo(s)%8)
+			return next
+		}
+	case 6, 7:
+		return func(s *compiledState) int {
+			value, ok := divideA(s)
+			if !ok {
+				return halt
+			}
+			if instr == 6 {
+				s.B = value
+			} else {
+				s.C = value
+			}
+			return next
+		}
+	}
+	return fail(fmt.Errorf("invalid instruction %d at pc %d", instr, pc))
+}
+
+// run executes the program with the given registers and returns its output, which is
+// only valid until the next run
+func (p *CompiledProgram) run(A, B, C int) ([]int, error) {
+	s := &p.state
+	s.A, s.B, s.C, s.output, s.err = A, B, C, s.output[:0], nil
+	pc := 0
+	for pc >= 0 && pc < len(p.ops) {
+		pc = p.ops[pc](s)
+	}
+	// as Computer.step, a jump before the start of the program is an error
+	if pc < 0 && s.err == nil {
+		s.err = fmt.Errorf("invalid pc %d", pc)
+	}
+	return s.output, s.err
+}
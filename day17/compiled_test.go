@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// the benchmarks run the puzzle program over the first benchValues values of A
+var benchProgram = []int{2, 4, 1, 3, 7, 5, 1, 5, 0, 3, 4, 3, 5, 5, 3, 0}
+
+const benchValues = 1024
+
+func TestCompiledMatchesInterpreter(t *testing.T) {
+	r := rand.New(rand.NewSource(17))
+	for _, program := range [][]int{benchProgram, {0, 3, 5, 4, 3, 0}, {0, 1, 5, 4, 3, 0}} {
+		compiled := compile(program)
+		values := []int{0, 1, 729, 117440, 47006051, 236548287712877}
+		for range 1000 {
+			values = append(values, r.Intn(1<<48))
+		}
+		for _, A := range values {
+			c := Computer{A: A, program: program}
+			want, wantErr := c.run()
+			output, err := compiled.run(A, 0, 0)
+			got := make([]string, len(output))
+			for i, o := range output {
+				got[i] = strconv.Itoa(o)
+			}
+			if strings.Join(got, ",") != want || (err == nil) != (wantErr == nil) {
+				t.Fatalf("program %v, A=%d: compiled gives %v (%v), interpreter %s (%v)",
+					program, A, output, err, want, wantErr)
+			}
+		}
+	}
+}
+
+func BenchmarkInterpreter(b *testing.B) {
+	for range b.N {
+		for A := range benchValues {
+			c := Computer{A: A, program: benchProgram}
+			if _, err := c.run(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkBigInterpreter(b *testing.B) {
+	for range b.N {
+		for A := range benchValues {
+			c := initializeBigComputer(InitialState{A: A, program: benchProgram})
+			if _, err := c.run(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkCompiled(b *testing.B) {
+	compiled := compile(benchProgram)
+	for range b.N {
+		for A := range benchValues {
+			if _, err := compiled.run(A, 0, 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Debugger runs a computer step by step, stopping on breakpoints set on the
+// instruction pointer and after at most maxSteps steps (0 means no limit)
+type Debugger struct {
+	c           *Computer
+	breakpoints map[int]bool
+	maxSteps    int
+	steps       int
+}
+
+func newDebugger(c *Computer, maxSteps int) *Debugger {
+	return &Debugger{c: c, breakpoints: map[int]bool{}, maxSteps: maxSteps}
+}
+
+const debuggerHelp = `Commands:
+  s, step [n]         execute n instructions (default 1)
+  c, continue         run until a breakpoint, the step limit or the end
+  b, break <pc>       set a breakpoint on pc
+  d, delete <pc>      remove the breakpoint on pc
+  r, regs             show pc and registers
+  set <A|B|C> <value> set a register
+  limit <n>           set the step limit, 0 for no limit
+  o, out              show the output so far
+  l, list             disassemble the program
+  q, quit             exit the debugger`
+
+// stepOnce executes one instruction, failing if the program halted or the step
+// limit was reached
+func (d *Debugger) stepOnce() error {
+	if d.c.halted() {
+		return fmt.Errorf("program halted")
+	}
+	if d.maxSteps > 0 && d.steps >= d.maxSteps {
+		return fmt.Errorf("step limit of %d reached", d.maxSteps)
+	}
+	if err := d.c.step(); err != nil {
+		return err
+	}
+	d.steps++
+	return nil
+}
+
+// cont runs until the next breakpoint, always executing at least one instruction
+// so that continuing from a breakpoint moves forward
+func (d *Debugger) cont() error {
+	for {
+		if err := d.stepOnce(); err != nil {
+			return err
+		}
+		if d.c.halted() || d.breakpoints[d.c.pc] {
+			return nil
+		}
+	}
+}
+
+func (d *Debugger) status() string {
+	if d.c.halted() {
+		return fmt.Sprintf("halted after %d steps, A=%d B=%d C=%d",
+			d.steps, d.c.A, d.c.B, d.c.C)
+	}
+	if d.c.pc < 0 {
+		return fmt.Sprintf("invalid pc %d after %d steps", d.c.pc, d.steps)
+	}
+	entry := TraceEntry{PC: d.c.pc, Opcode: d.c.program[d.c.pc], A: d.c.A, B: d.c.B, C: d.c.C}
+	if d.c.pc+1 < len(d.c.program) {
+		entry.Operand = d.c.program[d.c.pc+1]
+	}
+	return fmt.Sprintf("step %d: %v", d.steps, entry)
+}
+
+func (d *Debugger) list() string {
+	var lines []string
+	for pc := 0; pc+1 < len(d.c.program); pc += 2 {
+		marker := "  "
+		if d.breakpoints[pc] {
+			marker = "* "
+		}
+		if pc == d.c.pc {
+			marker = marker[:1] + ">"
+		}
+		mnemonic := "???"
+		if op := d.c.program[pc]; op >= 0 && op < len(mnemonics) {
+			mnemonic = mnemonics[op]
+		}
+		lines = append(lines, fmt.Sprintf("%s%3d %s %d", marker, pc, mnemonic, d.c.program[pc+1]))
+	}
+	if d.c.pc < 0 {
+		lines = append(lines, fmt.Sprintf(" >%3d outside of the program", d.c.pc))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exec runs a single command line and returns what to print and whether to quit
+func (d *Debugger) exec(line string) (string, bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", false, nil
+	}
+	args := fields[1:]
+	intArg := func(i int) (int, error) {
+		if len(args) <= i {
+			return 0, fmt.Errorf("missing argument")
+		}
+		return strconv.Atoi(args[i])
+	}
+
+	switch fields[0] {
+	case "s", "step":
+		n := 1
+		if len(args) > 0 {
+			var err error
+			if n, err = intArg(0); err != nil {
+				return "", false, err
+			}
+		}
+		for range n {
+			if err := d.stepOnce(); err != nil {
+				return d.status(), false, err
+			}
+			if d.c.halted() {
+				break
+			}
+		}
+		return d.status(), false, nil
+	case "c", "continue":
+		err := d.cont()
+		return d.status(), false, err
+	case "b", "break", "d", "delete":
+		pc, err := intArg(0)
+		if err != nil {
+			return "", false, err
+		}
+		if pc < 0 || pc >= len(d.c.program) || pc%2 != 0 {
+			return "", false, fmt.Errorf("invalid pc %d", pc)
+		}
+		if fields[0][0] == 'b' {
+			d.breakpoints[pc] = true
+		} else {
+			delete(d.breakpoints, pc)
+		}
+		pcs := make([]int, 0, len(d.breakpoints))
+		for pc := range d.breakpoints {
+			pcs = append(pcs, pc)
+		}
+		slices.Sort(pcs)
+		return fmt.Sprint("breakpoints: ", pcs), false, nil
+	case "r", "regs":
+		return d.status(), false, nil
+	case "set":
+		value, err := intArg(1)
+		if err != nil {
+			return "", false, err
+		}
+		switch strings.ToUpper(args[0]) {
+		case "A":
+			d.c.A = value
+		case "B":
+			d.c.B = value
+		case "C":
+			d.c.C = value
+		default:
+			return "", false, fmt.Errorf("unknown register %s", args[0])
+		}
+		return d.status(), false, nil
+	case "limit":
+		n, err := intArg(0)
+		if err != nil {
+			return "", false, err
+		}
+		d.maxSteps = n
+		return fmt.Sprint("step limit: ", n), false, nil
+	case "o", "out":
+		return d.c.getOutput(), false, nil
+	case "l", "list":
+		return d.list(), false, nil
+	case "h", "help":
+		return debuggerHelp, false, nil
+	case "q", "quit":
+		return "", true, nil
+	}
+	return "", false, fmt.Errorf("unknown command %s, type help for the list", fields[0])
+}
+
+// repl reads commands from in and writes the results to out until quit or EOF
+func (d *Debugger) repl(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	fmt.Fprintln(out, d.status())
+	for {
+		fmt.Fprint(out, "(dbg) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		result, quit, err := d.exec(scanner.Text())
+		if result != "" {
+			fmt.Fprintln(out, result)
+		}
+		if err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+		if quit {
+			return nil
+		}
+	}
+}
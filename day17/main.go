@@ -2,9 +2,14 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math/big"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -36,6 +41,8 @@ import (
 type InitialState struct {
 	A, B, C int
 	program []int
+	// the registers as read, which can be larger than an int in big mode
+	bigA, bigB, bigC *big.Int
 }
 
 func readInput() InitialState {
@@ -54,16 +61,21 @@ func readInput() InitialState {
 	var is InitialState
 	scanner := bufio.NewScanner(file)
 
-	for _, register := range []*int{&is.A, &is.B, &is.C} {
+	is.bigA, is.bigB, is.bigC = new(big.Int), new(big.Int), new(big.Int)
+	for i, register := range []*int{&is.A, &is.B, &is.C} {
 		scanner.Scan()
 		_, regText, ok := strings.Cut(scanner.Text(), ": ")
 		if !ok {
 			log.Fatal("Invalid register")
 		}
-		*register, err = strconv.Atoi(regText)
-		if !ok || err != nil {
+		bigRegister := []*big.Int{is.bigA, is.bigB, is.bigC}[i]
+		if _, ok := bigRegister.SetString(regText, 10); !ok {
 			log.Fatal("Invalid register value")
 		}
+		if !bigRegister.IsInt64() && !*bigFlag {
+			log.Fatal("Register value ", regText, " does not fit in an int, use -big")
+		}
+		*register = int(bigRegister.Int64())
 	}
 	scanner.Scan()
 	scanner.Scan()
@@ -76,6 +88,9 @@ func readInput() InitialState {
 		if err != nil {
 			log.Fatal("Invalid instruction")
 		}
+		if i < 0 {
+			log.Fatal("Invalid instruction ", i, ", program values cannot be negative")
+		}
 		is.program = append(is.program, i)
 	}
 	return is
@@ -86,6 +101,9 @@ type Computer struct {
 	program []int
 	pc      int
 	output  []int
+	// if trace is not nil, each step is logged to it before being executed
+	trace       io.Writer
+	traceFormat TraceFormat
 }
 
 func initializeComputer(is InitialState) Computer {
@@ -99,33 +117,109 @@ func initializeComputer(is InitialState) Computer {
 	}
 }
 
-func (c *Computer) combo(operand int) int {
-	if operand < 4 {
-		return operand
+type TraceFormat int
+
+const (
+	TraceText TraceFormat = iota
+	TraceJSON
+)
+
+// TraceEntry is the state of the computer right before an instruction is executed
+type TraceEntry struct {
+	PC      int `json:"pc"`
+	Opcode  int `json:"opcode"`
+	Operand int `json:"operand"`
+	A       int `json:"a"`
+	B       int `json:"b"`
+	C       int `json:"c"`
+}
+
+var mnemonics = [8]string{"adv", "bxl", "bst", "jnz", "bxc", "out", "bdv", "cdv"}
+
+func (e TraceEntry) String() string {
+	mnemonic := "???"
+	if e.Opcode >= 0 && e.Opcode < len(mnemonics) {
+		mnemonic = mnemonics[e.Opcode]
+	}
+	return fmt.Sprintf("pc=%d %s %d A=%d B=%d C=%d",
+		e.PC, mnemonic, e.Operand, e.A, e.B, e.C)
+}
+
+func (c *Computer) logStep(instr, operand int) error {
+	entry := TraceEntry{PC: c.pc, Opcode: instr, Operand: operand, A: c.A, B: c.B, C: c.C}
+	if c.traceFormat == TraceJSON {
+		return json.NewEncoder(c.trace).Encode(entry)
+	}
+	_, err := fmt.Fprintln(c.trace, entry)
+	return err
+}
+
+func (c *Computer) combo(operand int) (int, error) {
+	if operand >= 0 && operand < 4 {
+		return operand, nil
 	}
 	switch operand {
 	case 4:
-		return c.A
+		return c.A, nil
 	case 5:
-		return c.B
+		return c.B, nil
 	case 6:
-		return c.C
+		return c.C, nil
+	case 7:
+		return 0, fmt.Errorf("reserved combo operand 7 at pc %d", c.pc)
 	}
-	log.Fatal("Invalid combo operand")
-	return 0
+	return 0, fmt.Errorf("invalid combo operand %d at pc %d", operand, c.pc)
 }
 
-func (c *Computer) step() {
+func (c *Computer) halted() bool {
+	return c.pc >= len(c.program)
+}
+
+func (c *Computer) step() error {
+	// a jnz with a negative operand jumps before the start of the program
+	if c.pc < 0 {
+		return fmt.Errorf("invalid pc %d", c.pc)
+	}
+	if c.pc+1 >= len(c.program) {
+		return fmt.Errorf("missing operand at pc %d", c.pc)
+	}
 	instr := c.program[c.pc]
 	operand := c.program[c.pc+1]
+	if c.trace != nil {
+		if err := c.logStep(instr, operand); err != nil {
+			return err
+		}
+	}
+
+	// combo is only evaluated for the opcodes that use it, so that a literal operand
+	// equal to 7 does not raise an error
+	var value int
+	switch instr {
+	case 0, 2, 5, 6, 7:
+		var err error
+		if value, err = c.combo(operand); err != nil {
+			return err
+		}
+	case 1, 3, 4:
+	default:
+		return fmt.Errorf("invalid instruction %d at pc %d", instr, c.pc)
+	}
+
+	if instr == 0 || instr == 6 || instr == 7 {
+		var err error
+		if value, err = c.divideA(value); err != nil {
+			return err
+		}
+	}
+
 	c.pc += 2
 	switch instr {
 	case 0:
-		c.A /= 1 << c.combo(operand)
+		c.A = value
 	case 1:
 		c.B ^= operand
 	case 2:
-		c.B = c.combo(operand) % 8
+		c.B = value % 8
 	case 3:
 		if c.A != 0 {
 			c.pc = operand
@@ -133,21 +227,34 @@ func (c *Computer) step() {
 	case 4:
 		c.B ^= c.C
 	case 5:
-		c.output = append(c.output, c.combo(operand)%8)
+		c.output = append(c.output, value%8)
 	case 6:
-		c.B = c.A / (1 << c.combo(operand))
+		c.B = value
 	case 7:
-		c.C = c.A / (1 << c.combo(operand))
-	default:
-		log.Fatal("Invalid instruction")
+		c.C = value
+	}
+	return nil
+}
+
+// divideA returns A divided by 2 to the power of exp, which is 0 when the power does
+// not fit in an int instead of a division by zero
+func (c *Computer) divideA(exp int) (int, error) {
+	if exp < 0 {
+		return 0, fmt.Errorf("negative power of 2 at pc %d", c.pc)
+	}
+	if exp >= strconv.IntSize-1 {
+		return 0, nil
 	}
+	return c.A / (1 << exp), nil
 }
 
-func (c *Computer) run() string {
-	for c.pc < len(c.program) {
-		c.step()
+func (c *Computer) run() (string, error) {
+	for !c.halted() {
+		if err := c.step(); err != nil {
+			return c.getOutput(), err
+		}
 	}
-	return c.getOutput()
+	return c.getOutput(), nil
 }
 
 func (c *Computer) getOutput() string {
@@ -163,8 +270,19 @@ func (c *Computer) getOutput() string {
 
 func answer1() int {
 	initialState := readInput()
-	c := initializeComputer(initialState)
-	output := c.run()
+	var output string
+	var err error
+	if *bigFlag {
+		c := initializeBigComputer(initialState)
+		output, err = c.run()
+	} else {
+		c := initializeComputer(initialState)
+		c.trace, c.traceFormat = traceOutput()
+		output, err = c.run()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 	fmt.Println(output)
 	// return output as an int removing all commas
 	outputInt, err := strconv.Atoi(strings.ReplaceAll(output, ",", ""))
@@ -191,54 +309,155 @@ func answer1() int {
 // 6 - 5,5 - out 5 -> output B % 8
 // 7 - 3,0 - jnz 0 -> if A != 0 jump to 0
 
-// The program is equivalent to:
-// output ((((A % 8) ^ 3) ^ 5) ^ (A / 2 ** ((A % 8) ^ 3))) % 8
-// A = A / 8
-// if A != 0 jump to 0
+// The program is a loop that at each iteration outputs a value which depends only on A
+// (B and C are recomputed from A before being read), then shifts A right by 3 bits
+// and jumps back to the start until A is 0.
 
-// At the last iteration A must be less than 8 and the output expression above must
-// be equal to the program's last value. I can use that to find the last value of A.
+// At the last iteration A must be less than 8 and its output must be equal to the
+// program's last value. I can use that to find the last value of A.
 // Then at the run before that, A shall be so that A / 8 gives me the last value
 // of A and so on until I find the initial value of A
 
-func outputForA(A int) int {
-	return ((((A % 8) ^ 3) ^ 5) ^ (A/(1<<((A%8)^3)))%8)
-}
+// Instead of hardcoding the decompiled expression, I check that the program has the
+// loop shape described above and use the computer itself to run one iteration of the
+// loop for each candidate A, so that any program with the same shape can be solved.
 
-func minInt(slice []int) int {
-	if len(slice) == 0 {
-		log.Fatal("Cannot find the minimum of an empty slice")
+// checkLoopShape returns an error if the program is not a loop that outputs exactly
+// one value per iteration depending only on A, shifts A by 3 bits and jumps to 0.
+func checkLoopShape(program []int) error {
+	if len(program)%2 != 0 {
+		return fmt.Errorf("program has odd length %d", len(program))
+	}
+	if len(program) < 4 || program[len(program)-2] != 3 || program[len(program)-1] != 0 {
+		return fmt.Errorf("program does not end with jnz 0")
 	}
-	min := slice[0]
-	for _, val := range slice[1:] {
-		if val < min {
-			min = val
+	var advs, outs int
+	writtenB, writtenC := false, false
+	readsCombo := func(operand int) error {
+		switch {
+		case operand == 5 && !writtenB:
+			return fmt.Errorf("register B is read before being set from A")
+		case operand == 6 && !writtenC:
+			return fmt.Errorf("register C is read before being set from A")
+		case operand == 7:
+			return fmt.Errorf("reserved combo operand 7")
+		}
+		return nil
+	}
+	body := program[:len(program)-2]
+	for pc := 0; pc < len(body); pc += 2 {
+		instr, operand := body[pc], body[pc+1]
+		var err error
+		switch instr {
+		case 0:
+			if operand != 3 {
+				return fmt.Errorf("adv at %d does not shift A by 3 bits", pc)
+			}
+			advs++
+		case 1:
+			if !writtenB {
+				err = fmt.Errorf("register B is read before being set from A")
+			}
+		case 2:
+			err = readsCombo(operand)
+			writtenB = true
+		case 3:
+			return fmt.Errorf("jnz at %d is not the last instruction", pc)
+		case 4:
+			if !writtenB || !writtenC {
+				err = fmt.Errorf("registers B and C must be set from A before bxc")
+			}
+		case 5:
+			err = readsCombo(operand)
+			outs++
+		case 6:
+			err = readsCombo(operand)
+			writtenB = true
+		case 7:
+			err = readsCombo(operand)
+			writtenC = true
+		default:
+			return fmt.Errorf("invalid instruction %d at %d", instr, pc)
 		}
+		if err != nil {
+			return fmt.Errorf("instruction %d at %d: %w", instr, pc, err)
+		}
+	}
+	if advs != 1 {
+		return fmt.Errorf("expected exactly one adv 3 per loop, found %d", advs)
 	}
-	return min
+	if outs != 1 {
+		return fmt.Errorf("expected exactly one out per loop, found %d", outs)
+	}
+	return nil
 }
 
-func answer2() int {
-	initialState := readInput()
-	lenProgram := len(initialState.program)
+// outputForA runs one iteration of the loop (without the final jnz) starting with
+// register A and returns its output. The program must pass checkLoopShape.
+func outputForA(program []int, A int) (int, error) {
+	c := Computer{A: A, program: program[:len(program)-2]}
+	if _, err := c.run(); err != nil {
+		return 0, err
+	}
+	return c.output[0], nil
+}
+
+// findQuine returns the minimum value for register A that makes the program output
+// itself, searching backwards three bits at a time
+func findQuine(program []int) (int, error) {
+	if err := checkLoopShape(program); err != nil {
+		return 0, fmt.Errorf("program is not a quine candidate: %w", err)
+	}
+	if 3*len(program) > strconv.IntSize-1 {
+		return 0, fmt.Errorf("the quine of a program of length %d does not fit in an int, use -big",
+			len(program))
+	}
 	As := []int{0}
-	for i := lenProgram - 1; i >= 0; i-- {
-		programValue := initialState.program[i]
+	for i := len(program) - 1; i >= 0; i-- {
 		newAs := []int{}
 		for _, A := range As {
 			for remainder := 0; remainder < 8; remainder++ {
 				candidate := A*8 + remainder
-				if outputForA(candidate) == programValue {
+				output, err := outputForA(program, candidate)
+				if err != nil {
+					return 0, err
+				}
+				if output == program[i] {
 					newAs = append(newAs, candidate)
 				}
 			}
-			As = newAs
 		}
+		As = newAs
 	}
-	if len(As) == 0 {
-		log.Fatal("No solution found")
+	slices.Sort(As)
+	for _, A := range As {
+		// a candidate A could be 0 at an intermediate iteration, which halts the
+		// program early, so double check each candidate on a full run
+		c := initializeComputer(InitialState{A: A, program: program})
+		if _, err := c.run(); err == nil && slices.Equal(c.output, program) {
+			return A, nil
+		}
 	}
-	return minInt(As)
+	return 0, fmt.Errorf("no solution found")
+}
+
+func answer2() int {
+	initialState := readInput()
+	if *bigFlag {
+		A, err := findBigQuine(initialState.program)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !A.IsInt64() {
+			log.Fatal("Answer ", A, " does not fit in an int")
+		}
+		return int(A.Int64())
+	}
+	A, err := findQuine(initialState.program)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return A
 }
 
 // -----------------------------------------------------------------------
@@ -262,15 +481,49 @@ func printAndTest(question int) {
 	println(answer)
 }
 
+var (
+	traceFlag    = flag.String("trace", "", "trace each step of part 1 to stderr as text or json")
+	debugFlag    = flag.Bool("debug", false, "run the input program in the interactive debugger")
+	maxStepsFlag = flag.Int("max-steps", 0, "step limit for the debugger, 0 for no limit")
+	bigFlag      = flag.Bool("big", false, "use arbitrary precision registers")
+)
+
+// traceOutput returns where and how to trace according to the -trace flag
+func traceOutput() (io.Writer, TraceFormat) {
+	switch *traceFlag {
+	case "":
+		return nil, TraceText
+	case "text":
+		return os.Stderr, TraceText
+	case "json":
+		return os.Stderr, TraceJSON
+	}
+	log.Fatal("Invalid trace format ", *traceFlag, ", use text or json")
+	return nil, TraceText
+}
+
 func main() {
+	flag.Parse()
+	if *bigFlag && (*traceFlag != "" || *debugFlag) {
+		log.Fatal("-big cannot be combined with -trace or -debug")
+	}
+	if *debugFlag {
+		c := initializeComputer(readInput())
+		d := newDebugger(&c, *maxStepsFlag)
+		if err := d.repl(os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	args := flag.Args()
 	// if no argument, run all answers, otherwise only part 1 or 2
-	if len(os.Args) == 1 || os.Args[1] == "1" {
+	if len(args) == 0 || args[0] == "1" {
 		printAndTest(1)
 	}
-	if len(os.Args) == 1 || os.Args[1] == "2" {
+	if len(args) == 0 || args[0] == "2" {
 		printAndTest(2)
 	}
-	if len(os.Args) > 1 && os.Args[1] != "1" && os.Args[1] != "2" {
+	if len(args) > 0 && args[0] != "1" && args[0] != "2" {
 		println("Give 1 or 2 as argument, or no argument at all")
 	}
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"container/heap"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -29,8 +30,91 @@ type World struct {
 	end        Pos
 }
 
-func readInput() []Pos {
-	file, err := os.Open("input/day18")
+// Config describes the memory space and how many bytes have fallen for part 1.
+// It can be set in a header at the top of the input file, with lines such:
+// size=7x7
+// start=0,0
+// end=6,6
+// bytes=12
+// and each value can be overridden by the command line flag with the same name.
+// Without header and flags the puzzle values are used, with end in the
+// bottom-right corner.
+type Config struct {
+	width, height int
+	start, end    Pos
+	bytes         int
+}
+
+var defaultConfig = Config{width: 71, height: 71, start: Pos{0, 0}, bytes: 1024}
+
+var (
+	inputFlag = flag.String("input", "input/day18", "input file")
+	sizeFlag  = flag.String("size", "", "grid size as WxH")
+	startFlag = flag.String("start", "", "start position as x,y")
+	endFlag   = flag.String("end", "", "end position as x,y, default the bottom-right corner")
+	bytesFlag = flag.String("bytes", "", "number of fallen bytes for part 1")
+	timedFlag = flag.Bool("timed", false, "find the fastest route while byte i falls at time step i")
+	waitFlag  = flag.Bool("wait", false, "allow waiting in place in the timed mode")
+)
+
+func parsePos(s string) (Pos, error) {
+	xStr, yStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return Pos{}, fmt.Errorf("invalid position %q", s)
+	}
+	x, err := strconv.Atoi(xStr)
+	if err != nil {
+		return Pos{}, err
+	}
+	y, err := strconv.Atoi(yStr)
+	if err != nil {
+		return Pos{}, err
+	}
+	return Pos{x, y}, nil
+}
+
+// set updates the config with a header or flag value
+func (c *Config) set(key, value string) error {
+	var err error
+	switch key {
+	case "size":
+		w, h, ok := strings.Cut(value, "x")
+		if !ok {
+			return fmt.Errorf("invalid size %q", value)
+		}
+		if c.width, err = strconv.Atoi(w); err != nil {
+			return err
+		}
+		if c.height, err = strconv.Atoi(h); err != nil {
+			return err
+		}
+		if c.width <= 0 || c.height <= 0 {
+			return fmt.Errorf("invalid size %q, width and height must be positive", value)
+		}
+	case "start":
+		c.start, err = parsePos(value)
+	case "end":
+		c.end, err = parsePos(value)
+	case "bytes":
+		c.bytes, err = strconv.Atoi(value)
+	default:
+		err = fmt.Errorf("unknown setting %q", key)
+	}
+	return err
+}
+
+func (c Config) newWorld() *World {
+	return &World{
+		corrupted: map[Pos]bool{},
+		maxX:      c.width - 1,
+		maxY:      c.height - 1,
+		start:     c.start,
+		end:       c.end,
+	}
+}
+
+func readInput() (Config, []Pos) {
+	file, err := os.Open(*inputFlag)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -38,23 +122,51 @@ func readInput() []Pos {
 
 	scanner := bufio.NewScanner(file)
 	corrupted := []Pos{}
+	config := defaultConfig
+	endSet := false
 
 	for scanner.Scan() {
-		xStr, yStr, ok := strings.Cut(scanner.Text(), ",")
-		if !ok {
-			log.Fatal("Invalid input line: ", scanner.Text())
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			if len(corrupted) > 0 {
+				log.Fatal("Header line after the bytes: ", scanner.Text())
+			}
+			if err := config.set(key, value); err != nil {
+				log.Fatal(err)
+			}
+			endSet = endSet || key == "end"
+			continue
 		}
-		x, err := strconv.Atoi(xStr)
+		p, err := parsePos(scanner.Text())
 		if err != nil {
-			log.Fatal(err)
+			log.Fatal("Invalid input line: ", scanner.Text())
 		}
-		y, err := strconv.Atoi(yStr)
-		if err != nil {
+		corrupted = append(corrupted, p)
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name != "size" && f.Name != "start" && f.Name != "end" && f.Name != "bytes" {
+			return
+		}
+		if err := config.set(f.Name, f.Value.String()); err != nil {
 			log.Fatal(err)
 		}
-		corrupted = append(corrupted, Pos{x, y})
+		endSet = endSet || f.Name == "end"
+	})
+	if !endSet {
+		config.end = Pos{config.width - 1, config.height - 1}
+	}
+	for _, p := range []Pos{config.start, config.end} {
+		if p.x < 0 || p.x >= config.width || p.y < 0 || p.y >= config.height {
+			log.Fatalf("Position %d,%d outside of the %dx%d grid", p.x, p.y, config.width, config.height)
+		}
+	}
+	if config.bytes < 0 {
+		log.Fatal("Invalid number of bytes ", config.bytes)
+	}
+	if config.bytes > len(corrupted) {
+		log.Fatal("Only ", len(corrupted), " bytes in the input, ", config.bytes, " requested")
 	}
-	return corrupted
+	return config, corrupted
 }
 
 type Predecessor struct {
@@ -132,7 +244,7 @@ func (pm *PathManager) pop() Path {
 }
 
 func NewPathManager(w *World) *PathManager {
-	startEstimatedCost := Pos{0, 0}.estimateCost(w.end)
+	startEstimatedCost := w.start.estimateCost(w.end)
 	pq := PriorityQueue{startEstimatedCost}
 	heap.Init(&pq)
 	startPredecessor := Predecessor{Pos{-1, -1}, 0}
@@ -175,11 +287,9 @@ func (pm *PathManager) findPath() []Pos {
 }
 
 func answer1() int {
-	w := &World{
-		corrupted: map[Pos]bool{}, maxX: 70, maxY: 70, start: Pos{0, 0}, end: Pos{70, 70},
-	}
-	corrupted := readInput()
-	for i := 0; i < 1024; i++ {
+	config, corrupted := readInput()
+	w := config.newWorld()
+	for i := 0; i < config.bytes; i++ {
 		w.corrupted[corrupted[i]] = true
 	}
 	pm := NewPathManager(w)
@@ -193,24 +303,81 @@ func answer1() int {
 // Now consider the other lines of the input, which is the first additional corrupted
 // cell that cause the end to be unreachable?
 
-func answer2() int {
-	w := &World{
-		corrupted: map[Pos]bool{}, maxX: 70, maxY: 70, start: Pos{0, 0}, end: Pos{70, 70},
+// Instead of running A* again after each byte, we let all the bytes fall and then
+// remove them in reverse order, merging each freed cell with its free neighbours
+// in a union-find. The first byte whose removal connects start and end is the one
+// that blocks the path when falling, and the whole search is almost linear in the
+// size of the grid plus the number of bytes.
+
+// firstBlockingByte returns the first byte which makes the end unreachable from the
+// start, and its index in bytes. It returns false if the end is always reachable.
+func firstBlockingByte(w *World, bytes []Pos) (Pos, int, bool) {
+	width, height := w.maxX+1, w.maxY+1
+	cell := func(p Pos) int { return p.y*width + p.x }
+	inside := func(p Pos) bool { return p.x >= 0 && p.x <= w.maxX && p.y >= 0 && p.y <= w.maxY }
+
+	// a cell can be listed more than once, only its first fall matters
+	const never = -1
+	fallsAt := make([]int, width*height)
+	for i := range fallsAt {
+		fallsAt[i] = never
 	}
-	corrupted := readInput()
-	for i := 0; i < 1024; i++ {
-		w.corrupted[corrupted[i]] = true
+	for i, b := range bytes {
+		if !inside(b) {
+			log.Fatal("Byte outside of the grid: ", b)
+		}
+		if fallsAt[cell(b)] == never {
+			fallsAt[cell(b)] = i
+		}
 	}
-	for i := 1024; i < len(corrupted); i++ {
-		w.corrupted[corrupted[i]] = true
-		pm := NewPathManager(w)
-		bestRoute := pm.findPath()
-		if bestRoute == nil {
-			fmt.Printf("%d,%d\n", corrupted[i].x, corrupted[i].y)
-			return corrupted[i].x*100 + corrupted[i].y
+
+	ds := NewDisjointSet(width * height)
+	open := func(p Pos) {
+		for _, dir := range []Pos{{0, 1}, {0, -1}, {1, 0}, {-1, 0}} {
+			n := Pos{p.x + dir.x, p.y + dir.y}
+			if inside(n) && fallsAt[cell(n)] == never {
+				ds.Union(cell(p), cell(n))
+			}
 		}
 	}
-	panic("No solution found")
+	connected := func() bool {
+		return fallsAt[cell(w.start)] == never && fallsAt[cell(w.end)] == never &&
+			ds.Find(cell(w.start)) == ds.Find(cell(w.end))
+	}
+
+	for y := range height {
+		for x := range width {
+			if p := (Pos{x, y}); fallsAt[cell(p)] == never {
+				open(p)
+			}
+		}
+	}
+	if connected() {
+		return Pos{}, 0, false
+	}
+	for i := len(bytes) - 1; i >= 0; i-- {
+		b := bytes[i]
+		if fallsAt[cell(b)] != i {
+			continue
+		}
+		fallsAt[cell(b)] = never
+		open(b)
+		if connected() {
+			return b, i, true
+		}
+	}
+	log.Fatal("The end is unreachable even without bytes")
+	return Pos{}, 0, false
+}
+
+func answer2() int {
+	config, corrupted := readInput()
+	b, i, ok := firstBlockingByte(config.newWorld(), corrupted)
+	if !ok {
+		log.Fatal("No solution found")
+	}
+	fmt.Printf("%d,%d (byte %d)\n", b.x, b.y, i)
+	return b.x*100 + b.y
 }
 
 // -----------------------------------------------------------------------
@@ -235,14 +402,33 @@ func printAndTest(question int) {
 }
 
 func main() {
+	flag.Parse()
+	// the correct answers are only known for the puzzle input and settings
+	puzzleConfig := defaultConfig
+	puzzleConfig.end = Pos{defaultConfig.width - 1, defaultConfig.height - 1}
+	if config, _ := readInput(); *inputFlag != "input/day18" || config != puzzleConfig {
+		correctAnswers = map[int]int{}
+	}
+	if *timedFlag {
+		config, corrupted := readInput()
+		pm := NewTimedPathManager(config.newWorld(), corrupted, *waitFlag)
+		route := pm.findPath()
+		if route == nil {
+			fmt.Println("No route to the end")
+			return
+		}
+		fmt.Println(describeRoute(route))
+		return
+	}
+	args := flag.Args()
 	// if no argument, run all answers, otherwise only part 1 or 2
-	if len(os.Args) == 1 || os.Args[1] == "1" {
+	if len(args) == 0 || args[0] == "1" {
 		printAndTest(1)
 	}
-	if len(os.Args) == 1 || os.Args[1] == "2" {
+	if len(args) == 0 || args[0] == "2" {
 		printAndTest(2)
 	}
-	if len(os.Args) > 1 && os.Args[1] != "1" && os.Args[1] != "2" {
+	if len(args) > 0 && args[0] != "1" && args[0] != "2" {
 		println("Give 1 or 2 as argument, or no argument at all")
 	}
 }
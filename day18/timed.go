@@ -0,0 +1,161 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"strings"
+)
+
+// In the timed mode the bytes fall while we walk: byte i lands at time step i, so
+// that at time t the bytes 0..t are corrupted, and we can only move to (or wait in)
+// a cell at time t+1 if it is not corrupted at that time. The search state becomes
+// (pos, time) and we use A* on it with the same estimateCost heuristic, since each
+// step, moving or waiting, costs one time step.
+// Since corrupted cells never get free again waiting cannot make the fastest route
+// shorter, but it is still a valid move and the search explores it when enabled.
+
+type TimedState struct {
+	pos  Pos
+	time int
+}
+
+type TimedPath struct {
+	state         TimedState
+	estimatedCost int
+}
+
+type TimedPathManager struct {
+	world        *World
+	fallsAt      map[Pos]int // pos -> time at which the first byte lands on it
+	lastFall     int
+	wait         bool
+	costsHeap    PriorityQueue
+	paths        map[int][]TimedPath // estimated cost -> paths
+	predecessors map[TimedState]TimedState
+}
+
+func NewTimedPathManager(w *World, bytes []Pos, wait bool) *TimedPathManager {
+	fallsAt := map[Pos]int{}
+	for i, b := range bytes {
+		if _, ok := fallsAt[b]; !ok {
+			fallsAt[b] = i
+		}
+	}
+	startEstimatedCost := w.start.estimateCost(w.end)
+	pq := PriorityQueue{startEstimatedCost}
+	heap.Init(&pq)
+	startPath := TimedPath{TimedState{w.start, 0}, startEstimatedCost}
+	return &TimedPathManager{
+		world:        w,
+		fallsAt:      fallsAt,
+		lastFall:     len(bytes) - 1,
+		wait:         wait,
+		costsHeap:    pq,
+		paths:        map[int][]TimedPath{startEstimatedCost: {startPath}},
+		predecessors: map[TimedState]TimedState{},
+	}
+}
+
+// corrupted returns true if pos is corrupted at the given time
+func (pm *TimedPathManager) corrupted(pos Pos, time int) bool {
+	fallTime, ok := pm.fallsAt[pos]
+	return ok && fallTime <= time
+}
+
+func (pm *TimedPathManager) add(path TimedPath) {
+	cost := path.estimatedCost
+	if _, ok := pm.paths[cost]; !ok {
+		heap.Push(&pm.costsHeap, cost)
+	}
+	pm.paths[cost] = append(pm.paths[cost], path)
+}
+
+func (pm *TimedPathManager) pop() TimedPath {
+	cost, _ := pm.costsHeap.Peek()
+	path := pm.paths[cost][len(pm.paths[cost])-1]
+	pm.paths[cost] = pm.paths[cost][:len(pm.paths[cost])-1]
+	if len(pm.paths[cost]) == 0 {
+		heap.Pop(&pm.costsHeap)
+		delete(pm.paths, cost)
+	}
+	return path
+}
+
+// findPath returns the states of the fastest route from start to end, in order, or
+// nil if the end cannot be reached before being cut off by the bytes
+func (pm *TimedPathManager) findPath() []TimedState {
+	w := pm.world
+	if pm.corrupted(w.start, 0) {
+		return nil
+	}
+	// after the last fall the grid does not change anymore, so all the later times
+	// are the same state and the search space is finite
+	collapse := func(s TimedState) TimedState {
+		return TimedState{s.pos, min(s.time, pm.lastFall+1)}
+	}
+	// states are closed when popped: the heuristic is consistent, so the first time
+	// a state is popped it is with its earliest time
+	closed := map[TimedState]bool{}
+	for len(pm.paths) > 0 {
+		path := pm.pop()
+		if closed[collapse(path.state)] {
+			continue
+		}
+		closed[collapse(path.state)] = true
+		if path.state.pos == w.end {
+			return pm.route(path.state)
+		}
+		time := path.state.time + 1
+		moves := []Pos{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
+		// once all the bytes have fallen waiting cannot open new ways
+		if pm.wait && path.state.time <= pm.lastFall {
+			moves = append(moves, Pos{0, 0})
+		}
+		for _, dir := range moves {
+			newPos := Pos{path.state.pos.x + dir.x, path.state.pos.y + dir.y}
+			newState := TimedState{newPos, time}
+			if newPos.x < 0 || newPos.x > w.maxX ||
+				newPos.y < 0 || newPos.y > w.maxY ||
+				pm.corrupted(newPos, time) ||
+				closed[collapse(newState)] {
+				continue
+			}
+			// a state already pushed was reached at the same time, as good as now
+			if _, ok := pm.predecessors[newState]; ok {
+				continue
+			}
+			pm.predecessors[newState] = path.state
+			pm.add(TimedPath{newState, time + newPos.estimateCost(w.end)})
+		}
+	}
+	return nil
+}
+
+func (pm *TimedPathManager) route(end TimedState) []TimedState {
+	route := []TimedState{end}
+	for state := end; state.time > 0; {
+		state = pm.predecessors[state]
+		route = append(route, state)
+	}
+	for i, j := 0, len(route)-1; i < j; i, j = i+1, j-1 {
+		route[i], route[j] = route[j], route[i]
+	}
+	return route
+}
+
+// describeRoute returns the number of steps and waits of a timed route and its
+// positions, with a "w" after each position where we waited
+func describeRoute(route []TimedState) string {
+	var positions []string
+	waits := 0
+	for i, s := range route {
+		if i > 0 && route[i-1].pos == s.pos {
+			waits++
+			positions[len(positions)-1] += "w"
+			continue
+		}
+		positions = append(positions, fmt.Sprintf("%d,%d", s.pos.x, s.pos.y))
+	}
+	return fmt.Sprintf("%d steps, %d waits: %s",
+		len(route)-1, waits, strings.Join(positions, " "))
+}
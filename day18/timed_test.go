@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// bfsTimedSteps returns the number of steps of the fastest timed route with a
+// breadth-first search over (pos, time), or -1 if there is none
+func bfsTimedSteps(w *World, bytes []Pos, wait bool) int {
+	pm := NewTimedPathManager(w, bytes, wait)
+	if pm.corrupted(w.start, 0) {
+		return -1
+	}
+	collapse := func(s TimedState) TimedState {
+		return TimedState{s.pos, min(s.time, pm.lastFall+1)}
+	}
+	start := TimedState{w.start, 0}
+	visited := map[TimedState]bool{start: true}
+	for queue := []TimedState{start}; len(queue) > 0; queue = queue[1:] {
+		s := queue[0]
+		if s.pos == w.end {
+			return s.time
+		}
+		moves := []Pos{{0, 1}, {0, -1}, {1, 0}, {-1, 0}}
+		if wait {
+			moves = append(moves, Pos{0, 0})
+		}
+		for _, dir := range moves {
+			next := TimedState{Pos{s.pos.x + dir.x, s.pos.y + dir.y}, s.time + 1}
+			if next.pos.x < 0 || next.pos.x > w.maxX || next.pos.y < 0 || next.pos.y > w.maxY ||
+				pm.corrupted(next.pos, next.time) || visited[collapse(next)] {
+				continue
+			}
+			visited[collapse(next)] = true
+			queue = append(queue, next)
+		}
+	}
+	return -1
+}
+
+func timedSteps(w *World, bytes []Pos, wait bool) int {
+	route := NewTimedPathManager(w, bytes, wait).findPath()
+	return len(route) - 1
+}
+
+func squareWorld(n int) *World {
+	c := Config{width: n, height: n, end: Pos{n - 1, n - 1}}
+	return c.newWorld()
+}
+
+// routes that used to be 2 steps too long, when states were closed when pushed
+func TestTimedRegression(t *testing.T) {
+	for _, bytes := range [][]Pos{
+		{{4, 5}, {6, 5}, {3, 6}, {2, 2}, {4, 0}, {3, 6}, {3, 6}, {3, 4}},
+		{{2, 0}, {3, 6}, {4, 3}, {1, 0}, {4, 5}, {6, 5}, {0, 0}, {0, 3}},
+	} {
+		if got := timedSteps(squareWorld(7), bytes, false); got != 12 {
+			t.Errorf("bytes %v: got %d steps, want 12", bytes, got)
+		}
+	}
+}
+
+func TestTimedAgainstBFS(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := range 5000 {
+		n := 4 + r.Intn(6)
+		bytes := make([]Pos, r.Intn(3*n))
+		for j := range bytes {
+			bytes[j] = Pos{r.Intn(n), r.Intn(n)}
+		}
+		wait := i%2 == 1
+		want := bfsTimedSteps(squareWorld(n), bytes, wait)
+		if got := timedSteps(squareWorld(n), bytes, wait); got != want {
+			t.Fatalf("n=%d wait=%v bytes %v: got %d steps, want %d", n, wait, bytes, got, want)
+		}
+	}
+}
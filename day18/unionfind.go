@@ -0,0 +1,42 @@
+package main
+
+// DisjointSet is a union-find structure over the ints 0..n-1, with path
+// compression and union by size
+type DisjointSet struct {
+	parent []int
+	size   []int
+}
+
+func NewDisjointSet(n int) *DisjointSet {
+	ds := &DisjointSet{make([]int, n), make([]int, n)}
+	for i := range ds.parent {
+		ds.parent[i] = i
+		ds.size[i] = 1
+	}
+	return ds
+}
+
+// Find returns the representative of the set containing x
+func (ds *DisjointSet) Find(x int) int {
+	root := x
+	for ds.parent[root] != root {
+		root = ds.parent[root]
+	}
+	for ds.parent[x] != root {
+		ds.parent[x], x = root, ds.parent[x]
+	}
+	return root
+}
+
+// Union merges the sets containing x and y
+func (ds *DisjointSet) Union(x, y int) {
+	x, y = ds.Find(x), ds.Find(y)
+	if x == y {
+		return
+	}
+	if ds.size[x] < ds.size[y] {
+		x, y = y, x
+	}
+	ds.parent[y] = x
+	ds.size[x] += ds.size[y]
+}
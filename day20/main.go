@@ -2,13 +2,18 @@ package main
 
 import (
 	"bufio"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strconv"
 )
 
 // PART 1
 // Your input is a maze with walls '#' and open paths '.'.
 // Start is 'S' and end is 'E'. There is only one path from start to end.
+// (mazes with branches are supported too, see findCheats)
 // You can "cheat" by removing walls for two consecutive steps, but only once.
 // How many different "cheats" do save you at least 100 steps?
 // Uniquely identify a cheat by its start,end pair: start is the position you are in before
@@ -30,8 +35,7 @@ type World struct {
 }
 
 func readInput() World {
-	file, err := os.Open("input/day20")
-	// file, err := os.Open("input/day20_test")
+	file, err := os.Open(*inputFlag)
 	if err != nil {
 		log.Fatalf("error opening file: %v", err)
 	}
@@ -97,6 +101,22 @@ func (p Pos) distanceTo(dest Pos) int {
 	return abs(p.x-dest.x) + abs(p.y-dest.y)
 }
 
+// Metric is how the length of a cheat is measured: Manhattan when cheating moves in
+// the 4 directions as in the puzzle, Chebyshev when it moves in 8 directions
+type Metric int
+
+const (
+	Manhattan Metric = iota
+	Chebyshev
+)
+
+func (m Metric) distance(a, b Pos) int {
+	if m == Chebyshev {
+		return max(abs(a.x-b.x), abs(a.y-b.y))
+	}
+	return a.distanceTo(b)
+}
+
 type PathManager struct {
 	world        *World
 	paths        []Path
@@ -181,35 +201,156 @@ func (pm *PathManager) findPath() Route {
 	panic("No path found")
 }
 
-// countCheats returns the number of routes that save at least 100 steps
-// by removing walls for two steps
-func countCheats(route Route, minStepsToSave int, cheatDuration int) int {
-	cheatsCount := 0
-	// cheats have to start and end in a position along the ruote.
-	// to get all possible starts, we iterate over the first len(route)-minStepsToSave
-	// positions in the route and check if there is another pos in the route no more than
-	// cheatDuration steps away that can be the end of the cheat.
-	maxSteps := route.len() - minStepsToSave
-	for i := 0; i < maxSteps-1; i++ {
+// Cheat is a shortcut from start to end through walls, with length the steps it
+// takes and saving the steps it saves compared to following the route
+type Cheat struct {
+	start, end Pos
+	length     int
+	saving     int
+}
+
+// listCheats returns all the cheats that save at least minStepsToSave steps
+// by removing walls for at most cheatDuration steps
+func listCheats(route Route, minStepsToSave int, cheatDuration int, metric Metric) []Cheat {
+	cheats := []Cheat{}
+	// cheats have to start and end in a position along the route.
+	// for each start we check the positions at least minStepsToSave steps further
+	// along the route that are no more than cheatDuration steps away, since a cheat
+	// saves the difference between the steps along the route and its length.
+	for i := 0; i < len(route.pos); i++ {
 		cheatStart := route.pos[i]
 		for j := i + minStepsToSave; j < len(route.pos); j++ {
 			cheatEnd := route.pos[j]
-			distance := cheatStart.distanceTo(cheatEnd)
-			if distance <= cheatDuration && i+distance+route.len()-j <= maxSteps {
-				cheatsCount++
+			distance := metric.distance(cheatStart, cheatEnd)
+			if distance <= cheatDuration && j-i-distance >= minStepsToSave {
+				cheats = append(cheats, Cheat{cheatStart, cheatEnd, distance, j - i - distance})
+			}
+		}
+	}
+	return cheats
+}
+
+// In a maze with branches the route found by findPath is only one of the ways from
+// start to end, so cheats can also start and end off the route. For those we compute
+// the distance of each open position from the start and from the end: a cheat from a
+// to b of length d takes fromStart[a] + d + toEnd[b] steps in total.
+
+// distancesFrom returns the number of steps from p to each position reachable
+// from it, with -1 for unreachable positions and walls
+func (w *World) distancesFrom(p Pos) [][]int {
+	distances := make([][]int, w.maxY+1)
+	for y := range distances {
+		distances[y] = make([]int, w.maxX+1)
+		for x := range distances[y] {
+			distances[y][x] = -1
+		}
+	}
+	distances[p.y][p.x] = 0
+	queue := []Pos{p}
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+		for _, dir := range []Pos{{0, 1}, {0, -1}, {1, 0}, {-1, 0}} {
+			newPos := Pos{pos.x + dir.x, pos.y + dir.y}
+			if newPos.x < 0 || newPos.x > w.maxX || newPos.y < 0 || newPos.y > w.maxY ||
+				w.isWall(newPos) || distances[newPos.y][newPos.x] != -1 {
+				continue
+			}
+			distances[newPos.y][newPos.x] = distances[pos.y][pos.x] + 1
+			queue = append(queue, newPos)
+		}
+	}
+	return distances
+}
+
+// listMazeCheats is listCheats for any maze, not only a single corridor
+func listMazeCheats(w *World, minStepsToSave int, cheatDuration int, metric Metric) []Cheat {
+	fromStart := w.distancesFrom(w.start)
+	toEnd := w.distancesFrom(w.end)
+	best := fromStart[w.end.y][w.end.x]
+	if best == -1 {
+		log.Fatal("No path found")
+	}
+	cheats := []Cheat{}
+	for y := 0; y <= w.maxY; y++ {
+		for x := 0; x <= w.maxX; x++ {
+			if fromStart[y][x] == -1 {
+				continue
+			}
+			cheatStart := Pos{x, y}
+			for dy := -cheatDuration; dy <= cheatDuration; dy++ {
+				for dx := -cheatDuration; dx <= cheatDuration; dx++ {
+					cheatEnd := Pos{x + dx, y + dy}
+					distance := metric.distance(cheatStart, cheatEnd)
+					if distance > cheatDuration ||
+						cheatEnd.x < 0 || cheatEnd.x > w.maxX || cheatEnd.y < 0 || cheatEnd.y > w.maxY ||
+						toEnd[cheatEnd.y][cheatEnd.x] == -1 {
+						continue
+					}
+					saving := best - (fromStart[y][x] + distance + toEnd[cheatEnd.y][cheatEnd.x])
+					if saving >= minStepsToSave {
+						cheats = append(cheats, Cheat{cheatStart, cheatEnd, distance, saving})
+					}
+				}
+			}
+		}
+	}
+	return cheats
+}
+
+// isCorridor returns true if the route goes through all the open positions,
+// that is the maze is a single corridor from start to end
+func (w *World) isCorridor(route Route) bool {
+	open := 0
+	for _, row := range w.walls {
+		for _, wall := range row {
+			if !wall {
+				open++
 			}
 		}
 	}
-	return cheatsCount
+	return open == len(route.pos)
+}
+
+// findCheats returns all the cheats saving at least minStepsToSave steps, using the
+// original search along the route when the maze is a single corridor
+func findCheats(w *World, minStepsToSave int, cheatDuration int, metric Metric) []Cheat {
+	route := NewPathManager(w).findPath()
+	if w.isCorridor(route) {
+		return listCheats(route, minStepsToSave, cheatDuration, metric)
+	}
+	return listMazeCheats(w, minStepsToSave, cheatDuration, metric)
+}
+
+// savingsHistogram returns the number of cheats for each saving
+func savingsHistogram(cheats []Cheat) map[int]int {
+	histogram := map[int]int{}
+	for _, c := range cheats {
+		histogram[c.saving]++
+	}
+	return histogram
+}
+
+// printHistogram prints the histogram in the same form as the puzzle examples
+func printHistogram(histogram map[int]int) {
+	savings := make([]int, 0, len(histogram))
+	for saving := range histogram {
+		savings = append(savings, saving)
+	}
+	slices.Sort(savings)
+	for _, saving := range savings {
+		if count := histogram[saving]; count == 1 {
+			fmt.Printf("There is one cheat that saves %d picoseconds.\n", saving)
+		} else {
+			fmt.Printf("There are %d cheats that save %d picoseconds.\n", count, saving)
+		}
+	}
 }
 
 func answer1() int {
 	w := readInput()
-	minStepsToSave := 100
-	cheatDuration := 2
-	pm := NewPathManager(&w)
-	route := pm.findPath()
-	return countCheats(route, minStepsToSave, cheatDuration)
+	minStepsToSave, cheatDuration, metric := cheatRules(2)
+	return len(findCheats(&w, minStepsToSave, cheatDuration, metric))
 }
 
 // -----------------------------------------------------------------------
@@ -219,11 +360,8 @@ func answer1() int {
 
 func answer2() int {
 	w := readInput()
-	minStepsToSave := 100
-	cheatDuration := 20
-	pm := NewPathManager(&w)
-	route := pm.findPath()
-	return countCheats(route, minStepsToSave, cheatDuration)
+	minStepsToSave, cheatDuration, metric := cheatRules(20)
+	return len(findCheats(&w, minStepsToSave, cheatDuration, metric))
 }
 
 // -----------------------------------------------------------------------
@@ -247,15 +385,65 @@ func printAndTest(question int) {
 	println(answer)
 }
 
+var (
+	inputFlag     = flag.String("input", "input/day20", "input file")
+	minSaveFlag   = flag.Int("min-save", 100, "minimum number of steps a cheat must save")
+	durationFlag  = flag.Int("duration", 0, "cheat duration, default 2 for part 1 and 20 for part 2")
+	metricFlag    = flag.String("metric", "manhattan", "cheat distance, manhattan or chebyshev")
+	histogramFlag = flag.Bool("histogram", false,
+		"print how many cheats save each number of steps, at least -min-save, instead of the answers")
+)
+
+// cheatRules returns the minimum saving, the cheat duration and the metric set by
+// the flags, with defaultDuration if the duration is not set
+func cheatRules(defaultDuration int) (int, int, Metric) {
+	cheatDuration := defaultDuration
+	if *durationFlag > 0 {
+		cheatDuration = *durationFlag
+	}
+	var metric Metric
+	switch *metricFlag {
+	case "manhattan":
+		metric = Manhattan
+	case "chebyshev":
+		metric = Chebyshev
+	default:
+		log.Fatal("Invalid metric ", *metricFlag, ", use manhattan or chebyshev")
+	}
+	if *minSaveFlag < 1 {
+		log.Fatal("Invalid minimum saving ", *minSaveFlag, ", cheats must save at least 1 step")
+	}
+	return *minSaveFlag, cheatDuration, metric
+}
+
 func main() {
+	flag.Parse()
+	args := flag.Args()
+	flag.Visit(func(f *flag.Flag) {
+		// the correct answers are only known for the puzzle input and rules
+		correctAnswers = map[int]int{}
+	})
+	if *histogramFlag {
+		w := readInput()
+		for i, defaultDuration := range []int{2, 20} {
+			part := i + 1
+			if len(args) == 0 || args[0] == strconv.Itoa(part) {
+				minStepsToSave, cheatDuration, metric := cheatRules(defaultDuration)
+				fmt.Printf("Part %d, cheats up to %d steps saving at least %d:\n",
+					part, cheatDuration, minStepsToSave)
+				printHistogram(savingsHistogram(findCheats(&w, minStepsToSave, cheatDuration, metric)))
+			}
+		}
+		return
+	}
 	// if no argument, run all answers, otherwise only part 1 or 2
-	if len(os.Args) == 1 || os.Args[1] == "1" {
+	if len(args) == 0 || args[0] == "1" {
 		printAndTest(1)
 	}
-	if len(os.Args) == 1 || os.Args[1] == "2" {
+	if len(args) == 0 || args[0] == "2" {
 		printAndTest(2)
 	}
-	if len(os.Args) > 1 && os.Args[1] != "1" && os.Args[1] != "2" {
+	if len(args) > 0 && args[0] != "1" && args[0] != "2" {
 		println("Give 1 or 2 as argument, or no argument at all")
 	}
 }
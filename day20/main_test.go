@@ -0,0 +1,30 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+// the tables of the puzzle examples
+func TestExampleHistograms(t *testing.T) {
+	*inputFlag = "../input/day20_test"
+	w := readInput()
+	for _, tc := range []struct {
+		minSave, duration int
+		want              map[int]int
+	}{
+		{1, 2, map[int]int{2: 14, 4: 14, 6: 2, 8: 4, 10: 2, 12: 3, 20: 1, 36: 1, 38: 1, 40: 1, 64: 1}},
+		{50, 20, map[int]int{50: 32, 52: 31, 54: 29, 56: 39, 58: 25, 60: 23, 62: 20, 64: 19,
+			66: 12, 68: 14, 70: 12, 72: 22, 74: 4, 76: 3}},
+	} {
+		for name, cheats := range map[string][]Cheat{
+			"route": findCheats(&w, tc.minSave, tc.duration, Manhattan),
+			"maze":  listMazeCheats(&w, tc.minSave, tc.duration, Manhattan),
+		} {
+			if got := savingsHistogram(cheats); !maps.Equal(got, tc.want) {
+				t.Errorf("%s, duration %d, saving at least %d: got %v, want %v",
+					name, tc.duration, tc.minSave, got, tc.want)
+			}
+		}
+	}
+}
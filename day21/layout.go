@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Layout is a keypad defined as an ASCII grid, where each character other than a
+// space is a key and spaces are gaps robots cannot aim at. Keypads can have any
+// shape and any number of gaps. Every keypad needs an 'A' key, where arms start,
+// and keypads controlling a robot also need the '^', 'v', '<' and '>' keys.
+//
+// A definition file lists keypads, each introduced by a "keypad <name>" line and
+// followed by the rows of its grid up to an empty line. Lines starting with '#'
+// outside of the grids are comments. For example the puzzle keypads are:
+//
+//	keypad num
+//	789
+//	456
+//	123
+//	 0A
+//
+//	keypad dir
+//	 ^A
+//	<v>
+type Layout struct {
+	name string
+	keys map[rune]Pos
+	at   map[Pos]rune
+}
+
+const defaultLayouts = `keypad num
+789
+456
+123
+ 0A
+
+keypad dir
+ ^A
+<v>
+`
+
+// parseLayouts reads the keypads of a definition file in order
+func parseLayouts(r io.Reader) ([]*Layout, error) {
+	var layouts []*Layout
+	var current *Layout
+	y := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "keypad "); ok {
+			current = &Layout{name: strings.TrimSpace(name), keys: map[rune]Pos{},
+				at: map[Pos]rune{}}
+			layouts = append(layouts, current)
+			y = 0
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			current = nil
+			continue
+		}
+		if current == nil {
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+			return nil, fmt.Errorf("keypad row %q outside of a keypad", line)
+		}
+		for x, char := range []rune(line) {
+			if char == ' ' {
+				continue
+			}
+			if _, ok := current.keys[char]; ok {
+				return nil, fmt.Errorf("keypad %s: duplicate key %q", current.name, char)
+			}
+			current.keys[char] = Pos{x, y}
+			current.at[Pos{x, y}] = char
+		}
+		y++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, l := range layouts {
+		if _, ok := l.keys['A']; !ok {
+			return nil, fmt.Errorf("keypad %s has no A key", l.name)
+		}
+	}
+	return layouts, nil
+}
+
+// canControl returns true if the keypad has the keys to move a robot's arm
+func (l *Layout) canControl() bool {
+	for _, key := range "^v<>A" {
+		if _, ok := l.keys[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// orderings returns the moves of all the shortest ways from one key to another that
+// never aim at a gap, each followed by 'A' to press the key. When the keypad has no
+// gap in the way these are all the orderings of the horizontal and vertical moves,
+// otherwise they can include detours around the gaps.
+func (l *Layout) orderings(from, to rune) [][]rune {
+	// distances to the target key, to only follow moves getting closer to it
+	distances := map[Pos]int{l.keys[to]: 0}
+	queue := []Pos{l.keys[to]}
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+		for _, dir := range "^v<>" {
+			next := pos.move(dir)
+			if _, ok := l.at[next]; !ok {
+				continue
+			}
+			if _, ok := distances[next]; !ok {
+				distances[next] = distances[pos] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	var res [][]rune
+	var walk func(prefix []rune, pos Pos)
+	walk = func(prefix []rune, pos Pos) {
+		if distances[pos] == 0 {
+			res = append(res, append(append([]rune{}, prefix...), 'A'))
+			return
+		}
+		for _, dir := range "^v<>" {
+			next := pos.move(dir)
+			if d, ok := distances[next]; ok && d == distances[pos]-1 {
+				walk(append(prefix, dir), next)
+			}
+		}
+	}
+	if _, ok := distances[l.keys[from]]; ok {
+		walk(nil, l.keys[from])
+	}
+	return res
+}
+
+func (p Pos) move(dir rune) Pos {
+	switch dir {
+	case '^':
+		return Pos{p.x, p.y - 1}
+	case 'v':
+		return Pos{p.x, p.y + 1}
+	case '<':
+		return Pos{p.x - 1, p.y}
+	}
+	return Pos{p.x + 1, p.y}
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"bufio"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
 )
 
 // PART 1
@@ -35,27 +39,18 @@ import (
 // the shortest sequence and the numerical part of the code (ignoring leading 0s).
 // Return the sum of the complexities of all codes.
 
-type Code []int
-
 // numerical returns the numerical part of the code
-func (c Code) numerical() int {
+func numerical(code string) int {
 	result := 0
-	multiplier := 1
-	for i := len(c) - 1; i >= 0; i-- {
-		switch c[i] {
-		case A:
-			continue
-		default:
-			result += multiplier * (c[i])
-			multiplier *= 10
+	for _, char := range code {
+		if char >= '0' && char <= '9' {
+			result = result*10 + int(char-'0')
 		}
 	}
 	return result
 }
 
-const A int = 10
-
-func readInput() []Code {
+func readInput() []string {
 	file, err := os.Open("input/day21")
 	if err != nil {
 		log.Fatal(err)
@@ -63,24 +58,36 @@ func readInput() []Code {
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
-	var codes []Code
+	var codes []string
 
 	for scanner.Scan() {
-		code := make([]int, 0)
-		for _, char := range scanner.Text() {
-			if char == 'A' {
-				code = append(code, A)
-			} else {
-				// char is 0-9
-				code = append(code, int(char)-48)
-			}
-		}
-		codes = append(codes, code)
+		codes = append(codes, scanner.Text())
 	}
 
 	return codes
 }
 
+// The moveSets heuristic below works on the puzzle keypads only, hardcoded as
+// slices of key positions, where the keys of a code are ints
+
+type Code []int
+
+const A int = 10
+
+// toCode returns the keys of a code for the puzzle num keypad
+func toCode(s string) Code {
+	code := make([]int, 0)
+	for _, char := range s {
+		if char == 'A' {
+			code = append(code, A)
+		} else {
+			// char is 0-9
+			code = append(code, int(char)-48)
+		}
+	}
+	return code
+}
+
 const (
 	up int = iota
 	down
@@ -209,43 +216,20 @@ func precomputeDirMoves(targetMem, dirMem Memory) Memory {
 	return mem
 }
 
-func answer1() int {
+// heuristicComplexities returns the sum of the complexities of the codes with the
+// given number of robots on directional keypads, using the moveSets heuristic and
+// counting the [from, to] pairs of moves at each robot
+func heuristicComplexities(codes []string, robots int) int {
 	numpadMoves := precomputeMoves(numKeypadType)
 	dirpadMoves := precomputeMoves(dirKeypadType)
 	dirToNumMoves := precomputeDirMoves(numpadMoves, dirpadMoves)
-	dirToDirToNumMoves := precomputeDirMoves(dirToNumMoves, dirpadMoves)
 
 	res := 0
-	codes := readInput()
-	for _, code := range codes {
-		sequenceLen := 0
-		pos := A
-		for _, key := range code {
-			sequenceLen += len(dirToDirToNumMoves[[2]int{pos, key}])
-			pos = key
-		}
-		res += code.numerical() * sequenceLen
-	}
-	return res
-}
-
-// -----------------------------------------------------------------------
-
-// PART 2
-// Now instead of 2 directional robots, we have 25 of them controlling each other.
-// Find the new sum of complexities of all codes.
-func answer2() int {
-	numpadMoves := precomputeMoves(numKeypadType)
-	dirpadMoves := precomputeMoves(dirKeypadType)
-	dirToNumMoves := precomputeDirMoves(numpadMoves, dirpadMoves)
-
-	res := 0
-	codes := readInput()
 	for _, code := range codes {
 		sequenceLen := 0
 		movesToCount := map[[2]int]int{}
 		keyPos := A
-		for _, key := range code {
+		for _, key := range toCode(code) {
 			moves := dirToNumMoves[[2]int{keyPos, key}]
 			movePos := A
 			for _, move := range moves {
@@ -255,7 +239,7 @@ func answer2() int {
 			keyPos = key
 		}
 
-		for i := 1; i < 25; i++ {
+		for i := 1; i < robots; i++ {
 			newMovesToCount := map[[2]int]int{}
 			for fromTo, count := range movesToCount {
 				moves := dirpadMoves[fromTo]
@@ -270,11 +254,39 @@ func answer2() int {
 		for _, count := range movesToCount {
 			sequenceLen += count
 		}
-		res += code.numerical() * sequenceLen
+		res += numerical(code) * sequenceLen
+	}
+	return res
+}
+
+// exactComplexities returns the sum of the complexities of the codes computed with
+// the exact solver, solver_test.go checks that the heuristic agrees with it
+func exactComplexities(codes []string, robots int) int {
+	layouts := readLayouts(strings.NewReader(defaultLayouts))
+	solver, err := NewSolver(robotChain(layouts["dir"], layouts["num"], robots))
+	if err != nil {
+		log.Fatal(err)
+	}
+	res, err := solver.complexities(codes)
+	if err != nil {
+		log.Fatal(err)
 	}
 	return res
 }
 
+func answer1() int {
+	return exactComplexities(readInput(), 2)
+}
+
+// -----------------------------------------------------------------------
+
+// PART 2
+// Now instead of 2 directional robots, we have 25 of them controlling each other.
+// Find the new sum of complexities of all codes.
+func answer2() int {
+	return exactComplexities(readInput(), 25)
+}
+
 // -----------------------------------------------------------------------
 
 var correctAnswers = map[int]int{
@@ -296,15 +308,111 @@ func printAndTest(question int) {
 	println(answer)
 }
 
+// with any of the flags below, the sum of complexities for the chain of keypads set
+// by the flags is printed instead of the answers
+var (
+	robotsFlag  = flag.Int("robots", 2, "number of robots typing on dir keypads")
+	keypadsFlag = flag.String("keypads", "", "keypad definition file, default the puzzle keypads num and dir")
+	chainFlag   = flag.String("chain", "",
+		"comma separated keypads from the one we type on to the door, instead of -robots")
+	showFlag = flag.Bool("show", false,
+		"also print and replay the shortest sequence for each code, for short chains only")
+)
+
+// readLayouts returns the keypads of a definition file by name
+func readLayouts(r io.Reader) map[string]*Layout {
+	layouts, err := parseLayouts(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+	byName := map[string]*Layout{}
+	for _, l := range layouts {
+		byName[l.name] = l
+	}
+	return byName
+}
+
+// chainFromFlags returns the chain of keypads set by the flags
+func chainFromFlags() []*Layout {
+	var layouts map[string]*Layout
+	if *keypadsFlag == "" {
+		layouts = readLayouts(strings.NewReader(defaultLayouts))
+	} else {
+		file, err := os.Open(*keypadsFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		layouts = readLayouts(file)
+	}
+	var chain []*Layout
+	if *chainFlag != "" {
+		for _, name := range strings.Split(*chainFlag, ",") {
+			l, ok := layouts[name]
+			if !ok {
+				log.Fatal("Unknown keypad ", name)
+			}
+			chain = append(chain, l)
+		}
+	} else {
+		if layouts["dir"] == nil || layouts["num"] == nil {
+			log.Fatal("Without -chain the keypads must be named dir and num")
+		}
+		if *robotsFlag < 0 {
+			log.Fatal("Invalid number of robots ", *robotsFlag)
+		}
+		chain = robotChain(layouts["dir"], layouts["num"], *robotsFlag)
+	}
+	return chain
+}
+
+// customComplexities returns the sum of complexities for the chain of keypads set
+// by the flags, printing and replaying the sequences if requested
+func customComplexities(codes []string) int {
+	solver, err := NewSolver(chainFromFlags())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *showFlag {
+		for _, code := range codes {
+			sequence, err := solver.sequence(code, maxShownSequenceLen)
+			if err != nil {
+				log.Fatal(err)
+			}
+			typed, err := replay(solver.chain, sequence)
+			if err != nil {
+				log.Fatal("Replay of ", code, ": ", err)
+			}
+			if typed != code {
+				log.Fatal("Replay of ", code, " typed ", typed)
+			}
+			fmt.Printf("%s: %s (%d)\n", code, sequence, len(sequence))
+		}
+	}
+	res, err := solver.complexities(codes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return res
+}
+
 func main() {
+	flag.Parse()
+	custom := false
+	flag.Visit(func(*flag.Flag) { custom = true })
+	if custom {
+		fmt.Println(customComplexities(readInput()))
+		return
+	}
+	args := flag.Args()
 	// if no argument, run all answers, otherwise only part 1 or 2
-	if len(os.Args) == 1 || os.Args[1] == "1" {
+	if len(args) == 0 || args[0] == "1" {
 		printAndTest(1)
 	}
-	if len(os.Args) == 1 || os.Args[1] == "2" {
+	if len(args) == 0 || args[0] == "2" {
 		printAndTest(2)
 	}
-	if len(os.Args) > 1 && os.Args[1] != "1" && os.Args[1] != "2" {
+	if len(args) > 0 && args[0] != "1" && args[0] != "2" {
 		println("Give 1 or 2 as argument, or no argument at all")
 	}
 }
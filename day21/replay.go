@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sequences longer than this are not expanded, since their length grows
+// exponentially with the number of robots
+const maxShownSequenceLen = 100_000
+
+// sequence returns one of the shortest sequences to type on the first keypad of the
+// chain to type the code, failing if it is longer than maxLen
+func (s *Solver) sequence(code string, maxLen int) (string, error) {
+	sequenceLen, err := s.sequenceLen(code)
+	if err != nil {
+		return "", err
+	}
+	if sequenceLen > maxLen {
+		return "", fmt.Errorf("sequence for %s has %d keys, more than %d", code, sequenceLen, maxLen)
+	}
+	var sb strings.Builder
+	if err := s.expand(&sb, []rune(code), len(s.chain)-1); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// expand writes the keys to type on the first keypad to type the given keys on the
+// keypad at depth, picking for each pair of keys the cheapest ordering of moves
+func (s *Solver) expand(sb *strings.Builder, keys []rune, depth int) error {
+	if depth == 0 {
+		sb.WriteString(string(keys))
+		return nil
+	}
+	pos := 'A'
+	for _, key := range keys {
+		best, bestCost := []rune(nil), -1
+		for _, ordering := range s.chain[depth].orderings(pos, key) {
+			cost, err := s.sequenceCost(ordering, depth-1)
+			if err != nil {
+				return err
+			}
+			if bestCost == -1 || cost < bestCost {
+				best, bestCost = ordering, cost
+			}
+		}
+		if best == nil {
+			return fmt.Errorf("keypad %s: cannot move from %q to %q", s.chain[depth].name, pos, key)
+		}
+		if err := s.expand(sb, best, depth-1); err != nil {
+			return err
+		}
+		pos = key
+	}
+	return nil
+}
+
+// replay types the sequence on the first keypad of the chain and returns what is
+// typed on the last one, failing if a robot aims at a gap or presses a key
+// that is not a move or A
+func replay(chain []*Layout, sequence string) (string, error) {
+	// arms[i] is where the robot's arm on chain[i] aims, arms[0] is unused since
+	// we type on the first keypad directly
+	arms := make([]Pos, len(chain))
+	for i, l := range chain {
+		arms[i] = l.keys['A']
+	}
+	var typed strings.Builder
+	for n, key := range sequence {
+		if _, ok := chain[0].keys[key]; !ok {
+			return "", fmt.Errorf("key %d: no key %q on keypad %s", n, key, chain[0].name)
+		}
+		// follow the key pressed on each keypad up the chain until it moves an arm
+		// or reaches the last keypad
+		for depth := 1; ; depth++ {
+			if depth == len(chain) {
+				typed.WriteRune(key)
+				break
+			}
+			if key != 'A' {
+				if !strings.ContainsRune("^v<>", key) {
+					return "", fmt.Errorf("key %d: %q pressed on keypad %s does not control a robot",
+						n, key, chain[depth-1].name)
+				}
+				arms[depth] = arms[depth].move(key)
+				if _, ok := chain[depth].at[arms[depth]]; !ok {
+					return "", fmt.Errorf("key %d: robot on keypad %s panics aiming at a gap",
+						n, chain[depth].name)
+				}
+				break
+			}
+			key = chain[depth].at[arms[depth]]
+		}
+	}
+	return typed.String(), nil
+}
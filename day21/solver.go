@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// The moveSets heuristic picks a single ordering of the moves between two keys.
+// The Solver below does not rely on it: for each pair of keys it tries every
+// ordering of the moves that does not pass over a gap, and keeps the one that is
+// cheapest for the whole chain of robots, memoizing the cost of each
+// (from, to, depth) so that chains of any length can be handled.
+
+// Solver computes the exact length of the shortest sequence to type on the first
+// keypad of a chain, where each keypad controls the robot's arm on the next one
+// and the last keypad is the one the code is typed on
+type Solver struct {
+	chain []*Layout
+	memo  map[solverKey]int
+}
+
+type solverKey struct {
+	from, to rune
+	depth    int
+}
+
+func NewSolver(chain []*Layout) (*Solver, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("empty chain of keypads")
+	}
+	for _, l := range chain[:len(chain)-1] {
+		if !l.canControl() {
+			return nil, fmt.Errorf("keypad %s cannot control a robot", l.name)
+		}
+	}
+	return &Solver{chain: chain, memo: map[solverKey]int{}}, nil
+}
+
+// robotChain returns the chain of the puzzle: we type on a dir keypad controlling
+// robots which type on dir keypads, and the last robot types on the num keypad
+func robotChain(dir, num *Layout, robots int) []*Layout {
+	chain := []*Layout{}
+	for range robots + 1 {
+		chain = append(chain, dir)
+	}
+	return append(chain, num)
+}
+
+func addChecked(a, b int) (int, error) {
+	if a > math.MaxInt-b {
+		return 0, fmt.Errorf("sequence length overflows an int")
+	}
+	return a + b, nil
+}
+
+// cost returns the number of keys to type on the first keypad to move the arm on
+// the keypad at the given depth in the chain from one key to another and press it.
+// The first keypad, at depth 0, is typed on directly.
+func (s *Solver) cost(from, to rune, depth int) (int, error) {
+	if depth == 0 {
+		return 1, nil
+	}
+	key := solverKey{from, to, depth}
+	if c, ok := s.memo[key]; ok {
+		return c, nil
+	}
+	best := -1
+	for _, ordering := range s.chain[depth].orderings(from, to) {
+		total, err := s.sequenceCost(ordering, depth-1)
+		if err != nil {
+			return 0, err
+		}
+		if best == -1 || total < best {
+			best = total
+		}
+	}
+	if best == -1 {
+		return 0, fmt.Errorf("keypad %s: cannot move from %q to %q", s.chain[depth].name, from, to)
+	}
+	s.memo[key] = best
+	return best, nil
+}
+
+// sequenceCost returns the cost of typing the keys in order on the keypad at the
+// given depth, with the arm starting aiming at A
+func (s *Solver) sequenceCost(keys []rune, depth int) (int, error) {
+	total, pos := 0, 'A'
+	for _, key := range keys {
+		if _, ok := s.chain[depth].keys[key]; !ok {
+			return 0, fmt.Errorf("keypad %s has no key %q", s.chain[depth].name, key)
+		}
+		c, err := s.cost(pos, key, depth)
+		if err != nil {
+			return 0, err
+		}
+		if total, err = addChecked(total, c); err != nil {
+			return 0, err
+		}
+		pos = key
+	}
+	return total, nil
+}
+
+// sequenceLen returns the length of the shortest sequence to type the code
+func (s *Solver) sequenceLen(code string) (int, error) {
+	return s.sequenceCost([]rune(code), len(s.chain)-1)
+}
+
+// complexities returns the sum of the complexities of the codes
+func (s *Solver) complexities(codes []string) (int, error) {
+	res := 0
+	for _, code := range codes {
+		sequenceLen, err := s.sequenceLen(code)
+		if err != nil {
+			return 0, err
+		}
+		numerical := numerical(code)
+		if sequenceLen > 0 && numerical > math.MaxInt/sequenceLen {
+			return 0, fmt.Errorf("complexity of code %s overflows an int", code)
+		}
+		if res, err = addChecked(res, numerical*sequenceLen); err != nil {
+			return 0, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func puzzleSolver(t *testing.T, robots int) *Solver {
+	layouts := readLayouts(strings.NewReader(defaultLayouts))
+	solver, err := NewSolver(robotChain(layouts["dir"], layouts["num"], robots))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return solver
+}
+
+func TestExample(t *testing.T) {
+	codes := []string{"029A", "980A", "179A", "456A", "379A"}
+	got, err := puzzleSolver(t, 2).complexities(codes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 126384 {
+		t.Errorf("got %d, want 126384", got)
+	}
+}
+
+// the moveSets heuristic must agree with the exact solver on the puzzle codes
+func TestHeuristicAgreesWithSolver(t *testing.T) {
+	data, err := os.ReadFile("../input/day21")
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := strings.Fields(string(data))
+	for _, robots := range []int{2, 25} {
+		exact, err := puzzleSolver(t, robots).complexities(codes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if heuristic := heuristicComplexities(codes, robots); heuristic != exact {
+			t.Errorf("%d robots: the heuristic gives %d instead of %d", robots, heuristic, exact)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import "iter"
+
+// nextSecret only shifts and XORs the 24 bits of the secret, so it is a linear map
+// over GF(2) and can be written as a 24x24 bit matrix. Applying it n times is the
+// n-th power of the matrix, which takes O(log n) matrix products by squaring.
+// Each XOR with a shifted copy of itself can also be undone, so the map has an
+// inverse, prevSecret, and we can go back to the secret that produced another one.
+
+const secretBits = 24
+
+// BitMatrix is a 24x24 matrix over GF(2), where column j is the image of bit j
+type BitMatrix [secretBits]int
+
+// matrixOf returns the matrix of a linear map of the secrets
+func matrixOf(f func(int) int) BitMatrix {
+	var m BitMatrix
+	for j := range secretBits {
+		m[j] = f(1 << j)
+	}
+	return m
+}
+
+func identity() BitMatrix {
+	return matrixOf(func(secret int) int { return secret })
+}
+
+// apply returns the matrix times the secret, the XOR of the columns of its bits.
+// Only the lowest 24 bits of the secret count, as the first prune of nextSecret
+// drops the others.
+func (m BitMatrix) apply(secret int) int {
+	secret &= 1<<secretBits - 1
+	res := 0
+	for j := 0; secret != 0; j, secret = j+1, secret>>1 {
+		if secret&1 == 1 {
+			res ^= m[j]
+		}
+	}
+	return res
+}
+
+// mul returns the matrix of applying n and then m
+func (m BitMatrix) mul(n BitMatrix) BitMatrix {
+	var res BitMatrix
+	for j := range secretBits {
+		res[j] = m.apply(n[j])
+	}
+	return res
+}
+
+func (m BitMatrix) pow(n int) BitMatrix {
+	res := identity()
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			res = res.mul(m)
+		}
+		m = m.mul(m)
+	}
+	return res
+}
+
+var (
+	nextMatrix = matrixOf(nextSecret)
+	prevMatrix = matrixOf(prevSecret)
+)
+
+// undoShiftXor returns x such that secret = x ^ shift(x, k) on 24 bits. Since
+// shifting k bits is nilpotent its inverse is 1 + shift(k) + shift(2k) + ...
+func undoShiftXor(secret, k int, shift func(x, k int) int) int {
+	res := secret
+	for s := k; s < secretBits; s += k {
+		res ^= shift(secret, s)
+	}
+	return res & (1<<secretBits - 1)
+}
+
+func shiftLeft(x, k int) int  { return x << k }
+func shiftRight(x, k int) int { return x >> k }
+
+// prevSecret returns the secret whose next secret is the given one, undoing the
+// steps of nextSecret in reverse order
+func prevSecret(secret int) int {
+	secret = undoShiftXor(secret, 11, shiftLeft)
+	secret = undoShiftXor(secret, 5, shiftRight)
+	return undoShiftXor(secret, 6, shiftLeft)
+}
+
+// Generator is a buyer's sequence of secrets, positioned at one of them
+type Generator struct {
+	secret int
+}
+
+func NewGenerator(seed int) *Generator {
+	return &Generator{seed}
+}
+
+func (g *Generator) Secret() int {
+	return g.secret
+}
+
+// Next moves to the next secret and returns it
+func (g *Generator) Next() int {
+	g.secret = nextSecret(g.secret)
+	return g.secret
+}
+
+// Prev moves to the previous secret and returns it
+func (g *Generator) Prev() int {
+	g.secret = prevSecret(g.secret)
+	return g.secret
+}
+
+// Jump moves n secrets ahead, or back if n is negative, and returns the secret
+func (g *Generator) Jump(n int) int {
+	// the matrices only keep the lowest 24 bits, so do not touch the secret at all
+	if n == 0 {
+		return g.secret
+	}
+	if n > 0 {
+		g.secret = nextMatrix.pow(n).apply(g.secret)
+	} else {
+		g.secret = prevMatrix.pow(-n).apply(g.secret)
+	}
+	return g.secret
+}
+
+// Secrets returns the endless sequence of the secrets following the current one,
+// moving the generator as they are consumed
+func (g *Generator) Secrets() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for yield(g.Next()) {
+		}
+	}
+}
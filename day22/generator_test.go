@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPrevSecret(t *testing.T) {
+	r := rand.New(rand.NewSource(22))
+	for range 10000 {
+		x := r.Intn(1 << secretBits)
+		if got := prevSecret(nextSecret(x)); got != x {
+			t.Fatalf("prevSecret(nextSecret(%d)) = %d", x, got)
+		}
+	}
+}
+
+func TestJump(t *testing.T) {
+	r := rand.New(rand.NewSource(22))
+	seeds := []int{0, 1, 123, 1<<secretBits - 1, 1<<30 + 5, -5}
+	for range 20 {
+		seeds = append(seeds, r.Intn(1<<secretBits))
+	}
+	for _, seed := range seeds {
+		if got := NewGenerator(seed).Jump(0); got != seed {
+			t.Errorf("seed %d: Jump(0) = %d", seed, got)
+		}
+		want := seed
+		for n := 1; n <= 2000; n++ {
+			want = nextSecret(want)
+			if n%97 != 0 && n != 2000 {
+				continue
+			}
+			g := NewGenerator(seed)
+			if got := g.Jump(n); got != want {
+				t.Fatalf("seed %d: Jump(%d) = %d, want %d", seed, n, got, want)
+			}
+			if got := g.Jump(-n); got != seed&(1<<secretBits-1) {
+				t.Fatalf("seed %d: Jump(-%d) after Jump(%d) = %d", seed, n, n, got)
+			}
+		}
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	// the first secrets of the puzzle example
+	want := []int{15887950, 16495136, 527345, 704524, 1553684, 12683156, 11100544,
+		12249484, 7753432, 5908254}
+	var got []int
+	for secret := range NewGenerator(123).Secrets() {
+		got = append(got, secret)
+		if len(got) == len(want) {
+			break
+		}
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
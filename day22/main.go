@@ -2,9 +2,13 @@ package main
 
 import (
 	"bufio"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"runtime"
 	"strconv"
+	"strings"
 )
 
 // PART 1
@@ -55,14 +59,14 @@ func nextSecret(secret int) int {
 	return secret
 }
 
+// Instead of generating the 2000 secrets we jump straight to the last one with the
+// matrix of nextSecret to the power of 2000, see generator.go
+
 func answer1() int {
 	sum := 0
-	seeds := readInput()
-	for _, seed := range seeds {
-		for i := 0; i < 2000; i++ {
-			seed = nextSecret(seed)
-		}
-		sum += seed
+	jump := nextMatrix.pow(2000)
+	for _, seed := range readInput() {
+		sum += jump.apply(seed)
 	}
 	return sum
 }
@@ -99,8 +103,9 @@ func addSequencesToMemory(nums []int, memory map[[4]int]int) {
 	}
 }
 
-func answer2() int {
-	seeds := readInput()
+// mapMaxBananas is the first version of part 2, kept to compare with the faster one
+// in pipeline.go, see the benchmarks in pipeline_test.go
+func mapMaxBananas(seeds []int) int {
 	buyersNums := [][]int{}
 	for _, seed := range seeds {
 		nums := []int{seed}
@@ -123,6 +128,12 @@ func answer2() int {
 	return max
 }
 
+// The sequences are counted in an array split among workers, see pipeline.go
+
+func answer2() int {
+	return maxBananas(readInput(), 2000, runtime.NumCPU())
+}
+
 // -----------------------------------------------------------------------
 
 var correctAnswers = map[int]int{
@@ -144,15 +155,69 @@ func printAndTest(question int) {
 	println(answer)
 }
 
+var (
+	jumpFlag = flag.Int("jump", 0,
+		"print the secret of each buyer after n steps, or n steps before if negative, instead of the answers")
+	topFlag    = flag.Int("top", 0, "print the n sequences of changes with the most bananas instead of the answers")
+	reportFlag = flag.Bool("report", false,
+		"print the buyers selling for the best sequence of changes instead of the answers")
+	sequenceFlag = flag.String("sequence", "",
+		"with -report, the comma separated sequence of changes to report on instead of the best one")
+)
+
+// reportedSequence returns the index of the sequence set with -sequence, or of the
+// best one if not set
+func reportedSequence(seeds []int) (int, error) {
+	if *sequenceFlag == "" {
+		return topSequences(bananaTotals(seeds, 2000, runtime.NumCPU()), 1)[0], nil
+	}
+	fields := strings.Split(*sequenceFlag, ",")
+	if len(fields) != 4 {
+		return 0, fmt.Errorf("sequence %q: need 4 changes", *sequenceFlag)
+	}
+	var seq [4]int
+	for i, field := range fields {
+		change, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return 0, fmt.Errorf("sequence %q: %w", *sequenceFlag, err)
+		}
+		seq[i] = change
+	}
+	return indexOf(seq)
+}
+
 func main() {
+	flag.Parse()
+	if *jumpFlag != 0 {
+		for _, seed := range readInput() {
+			fmt.Println(seed, NewGenerator(seed).Jump(*jumpFlag))
+		}
+		return
+	}
+	if *topFlag > 0 {
+		writeTop(os.Stdout, readInput(), 2000, *topFlag)
+		return
+	}
+	if *reportFlag {
+		seeds := readInput()
+		index, err := reportedSequence(seeds)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := writeReport(os.Stdout, seeds, 2000, index); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	args := flag.Args()
 	// if no argument, run all answers, otherwise only part 1 or 2
-	if len(os.Args) == 1 || os.Args[1] == "1" {
+	if len(args) == 0 || args[0] == "1" {
 		printAndTest(1)
 	}
-	if len(os.Args) == 1 || os.Args[1] == "2" {
+	if len(args) == 0 || args[0] == "2" {
 		printAndTest(2)
 	}
-	if len(os.Args) > 1 && os.Args[1] != "1" && os.Args[1] != "2" {
+	if len(args) > 0 && args[0] != "1" && args[0] != "2" {
 		println("Give 1 or 2 as argument, or no argument at all")
 	}
 }
@@ -0,0 +1,68 @@
+package main
+
+import "sync"
+
+// The prices are single digits, so each change is in [-9, 9] and a sequence of four
+// changes fits in a base 19 number with four digits, which we use as the index of an
+// array instead of a map key. The index is updated as a rolling window while the
+// secrets are generated, so there is no need to store the secrets of a buyer.
+
+const changeSequences = 19 * 19 * 19 * 19
+
+// buyerBananas adds to totals the price paid by the buyer with the given seed for
+// each sequence of changes, the first time it appears in its steps secrets. seen
+// holds for each sequence the stamp of the last buyer it appeared for, so that the
+// same array can be reused for all the buyers of a worker without clearing it.
+func buyerBananas(seed, steps int, totals []int, seen []int32, stamp int32) {
+	secret, price, index := seed, seed%10, 0
+	for i := 1; i <= steps; i++ {
+		secret = nextSecret(secret)
+		newPrice := secret % 10
+		index = (index*19 + newPrice - price + 9) % changeSequences
+		price = newPrice
+		if i >= 4 && seen[index] != stamp {
+			seen[index] = stamp
+			totals[index] += price
+		}
+	}
+}
+
+// bananaTotals returns the bananas paid by all the buyers for each sequence of
+// changes, indexed as above, splitting the buyers among the given number of workers
+// each with its own totals, which are added up at the end
+func bananaTotals(seeds []int, steps, workers int) []int {
+	workers = max(1, min(workers, len(seeds)))
+	partials := make([][]int, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			totals := make([]int, changeSequences)
+			seen := make([]int32, changeSequences)
+			// worker w takes the buyers w, w+workers, w+2*workers...
+			for i := w; i < len(seeds); i += workers {
+				buyerBananas(seeds[i], steps, totals, seen, int32(i+1))
+			}
+			partials[w] = totals
+		}()
+	}
+	wg.Wait()
+
+	totals := partials[0]
+	for _, partial := range partials[1:] {
+		for i, v := range partial {
+			totals[i] += v
+		}
+	}
+	return totals
+}
+
+// maxBananas returns the most bananas we can get with a single sequence of changes
+func maxBananas(seeds []int, steps, workers int) int {
+	best := 0
+	for _, v := range bananaTotals(seeds, steps, workers) {
+		best = max(best, v)
+	}
+	return best
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+// benchSeeds returns n random seeds, about as many buyers as in the puzzle input
+func benchSeeds(n int) []int {
+	r := rand.New(rand.NewSource(22))
+	seeds := make([]int, n)
+	for i := range seeds {
+		seeds[i] = r.Intn(1 << secretBits)
+	}
+	return seeds
+}
+
+func TestMaxBananas(t *testing.T) {
+	seeds := benchSeeds(200)
+	want := mapMaxBananas(seeds)
+	for _, workers := range []int{1, 3, runtime.NumCPU()} {
+		if got := maxBananas(seeds, 2000, workers); got != want {
+			t.Errorf("%d workers: got %d bananas, want %d", workers, got, want)
+		}
+	}
+	// the example of the puzzle
+	if got := maxBananas([]int{1, 2, 3, 2024}, 2000, 2); got != 23 {
+		t.Errorf("example: got %d bananas, want 23", got)
+	}
+}
+
+func BenchmarkMap(b *testing.B) {
+	seeds := benchSeeds(1600)
+	for range b.N {
+		mapMaxBananas(seeds)
+	}
+}
+
+func BenchmarkArray(b *testing.B) {
+	seeds := benchSeeds(1600)
+	for range b.N {
+		maxBananas(seeds, 2000, 1)
+	}
+}
+
+func BenchmarkArrayWorkers(b *testing.B) {
+	seeds := benchSeeds(1600)
+	for range b.N {
+		maxBananas(seeds, 2000, runtime.NumCPU())
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Tools to explain the answer of part 2: which buyers sell for a sequence of
+// changes, when and for how much, and how the best sequences compare
+
+// sequenceOf returns the sequence of changes of an index of the bananaTotals array
+func sequenceOf(index int) [4]int {
+	var seq [4]int
+	for i := 3; i >= 0; i-- {
+		seq[i] = index%19 - 9
+		index /= 19
+	}
+	return seq
+}
+
+// indexOf returns the index of a sequence of changes in the bananaTotals array
+func indexOf(seq [4]int) (int, error) {
+	index := 0
+	for _, change := range seq {
+		if change < -9 || change > 9 {
+			return 0, fmt.Errorf("change %d out of range [-9, 9]", change)
+		}
+		index = index*19 + change + 9
+	}
+	return index, nil
+}
+
+// Trigger is a buyer selling the first time it sees a sequence of changes
+type Trigger struct {
+	buyer, seed int
+	step        int // the secret number at which the sequence ends, 1 to steps
+	price       int
+}
+
+// triggers returns the buyers selling for the sequence with the given index within
+// steps secrets, in input order
+func triggers(seeds []int, steps, index int) []Trigger {
+	var res []Trigger
+	for buyer, seed := range seeds {
+		secret, price, current := seed, seed%10, 0
+		for i := 1; i <= steps; i++ {
+			secret = nextSecret(secret)
+			newPrice := secret % 10
+			current = (current*19 + newPrice - price + 9) % changeSequences
+			price = newPrice
+			if i >= 4 && current == index {
+				res = append(res, Trigger{buyer, seed, i, price})
+				break
+			}
+		}
+	}
+	return res
+}
+
+// topSequences returns the indexes of the n sequences with the most bananas, most
+// bananas first and then in order of index
+func topSequences(totals []int, n int) []int {
+	indexes := make([]int, len(totals))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	slices.SortStableFunc(indexes, func(a, b int) int {
+		return cmp.Compare(totals[b], totals[a])
+	})
+	return indexes[:min(n, len(indexes))]
+}
+
+// writeTop writes the n sequences with the most bananas, with how many buyers sell
+func writeTop(w io.Writer, seeds []int, steps, n int) {
+	totals := bananaTotals(seeds, steps, 1)
+	for rank, index := range topSequences(totals, n) {
+		fmt.Fprintf(w, "%d. %v: %d bananas from %d buyers\n", rank+1, sequenceOf(index),
+			totals[index], len(triggers(seeds, steps, index)))
+	}
+}
+
+// writeReport writes the buyers selling for the sequence with the given index and
+// checks that their prices add up to the total of bananaTotals
+func writeReport(w io.Writer, seeds []int, steps, index int) error {
+	sells := triggers(seeds, steps, index)
+	total := 0
+	for _, t := range sells {
+		total += t.price
+	}
+	fmt.Fprintf(w, "sequence %v: %d bananas from %d of %d buyers\n",
+		sequenceOf(index), total, len(sells), len(seeds))
+	for _, t := range sells {
+		fmt.Fprintf(w, "buyer %d (seed %d): step %d, price %d\n", t.buyer+1, t.seed, t.step, t.price)
+	}
+	if expected := bananaTotals(seeds, steps, 1)[index]; total != expected {
+		return fmt.Errorf("report total %d differs from the counted %d", total, expected)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Tools to sanity check a network before running the clique solvers
+
+// components returns the connected components of the graph, each sorted, in order
+// of their smallest computer
+func (g *Graph) components() [][]int {
+	n := len(g.names)
+	seen := NewBitset(n)
+	var res [][]int
+	for v := range n {
+		if seen.Has(v) {
+			continue
+		}
+		seen.Set(v)
+		component := []int{v}
+		for i := 0; i < len(component); i++ {
+			for _, u := range g.adj[component[i]].AndNot(seen).Elements() {
+				seen.Set(u)
+				component = append(component, u)
+			}
+		}
+		slices.Sort(component)
+		res = append(res, component)
+	}
+	return res
+}
+
+func (g *Graph) degree(v int) int {
+	return g.adj[v].Count()
+}
+
+// degreeDistribution returns the number of computers for each number of links
+func (g *Graph) degreeDistribution() map[int]int {
+	res := map[int]int{}
+	for v := range g.names {
+		res[g.degree(v)]++
+	}
+	return res
+}
+
+// triangles returns the number of triangles each computer belongs to
+func (g *Graph) triangles() []int {
+	res := make([]int, len(g.names))
+	for v := range g.names {
+		for _, u := range g.adj[v].Elements() {
+			res[v] += g.adj[v].AndCount(g.adj[u])
+		}
+		// each triangle is counted from both the other computers
+		res[v] /= 2
+	}
+	return res
+}
+
+// clustering returns the clustering coefficient of each computer, the fraction of
+// pairs of its linked computers which are linked together
+func (g *Graph) clustering(triangles []int) []float64 {
+	res := make([]float64, len(g.names))
+	for v := range g.names {
+		if d := g.degree(v); d > 1 {
+			res[v] = 2 * float64(triangles[v]) / float64(d*(d-1))
+		}
+	}
+	return res
+}
+
+// writeStats writes a summary of the graph statistics
+func (g *Graph) writeStats(w io.Writer) {
+	edges := 0
+	for v := range g.names {
+		edges += g.degree(v)
+	}
+	fmt.Fprintf(w, "computers: %d, links: %d\n", len(g.names), edges/2)
+
+	components := g.components()
+	sizes := make([]int, len(components))
+	for i, c := range components {
+		sizes[i] = len(c)
+	}
+	slices.Sort(sizes)
+	slices.Reverse(sizes)
+	fmt.Fprintf(w, "connected components: %d, sizes %v\n", len(components), sizes)
+
+	distribution := g.degreeDistribution()
+	degrees := make([]int, 0, len(distribution))
+	for d := range distribution {
+		degrees = append(degrees, d)
+	}
+	slices.Sort(degrees)
+	fmt.Fprint(w, "degrees:")
+	for _, d := range degrees {
+		fmt.Fprintf(w, " %d:%d", d, distribution[d])
+	}
+	fmt.Fprintln(w)
+
+	triangles := g.triangles()
+	total := 0
+	for _, t := range triangles {
+		total += t
+	}
+	fmt.Fprintf(w, "triangles: %d\n", total/3)
+
+	average := 0.0
+	for _, c := range g.clustering(triangles) {
+		average += c
+	}
+	if len(g.names) > 0 {
+		average /= float64(len(g.names))
+	}
+	fmt.Fprintf(w, "average clustering coefficient: %.4f\n", average)
+
+	_, cores := g.degeneracyOrder()
+	coreSizes := map[int]int{}
+	maxCore := 0
+	for _, c := range cores {
+		coreSizes[c]++
+		maxCore = max(maxCore, c)
+	}
+	fmt.Fprint(w, "k-cores (k:computers in the k-core):")
+	inCore := 0
+	for k := maxCore; k >= 0; k-- {
+		inCore += coreSizes[k]
+		if coreSizes[k] > 0 {
+			fmt.Fprintf(w, " %d:%d", k, inCore)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// writeDOT writes the graph in Graphviz DOT format, with the computers and links
+// of the highlighted clique in red
+func (g *Graph) writeDOT(w io.Writer, highlight []int) {
+	highlighted := NewBitset(len(g.names))
+	for _, v := range highlight {
+		highlighted.Set(v)
+	}
+	fmt.Fprintln(w, "graph lan {")
+	for v, name := range g.names {
+		if highlighted.Has(v) {
+			fmt.Fprintf(w, "  %q [color=red, style=filled, fillcolor=pink];\n", name)
+		} else {
+			fmt.Fprintf(w, "  %q;\n", name)
+		}
+	}
+	for v, name := range g.names {
+		for _, u := range g.adj[v].Elements() {
+			if u < v {
+				continue
+			}
+			if highlighted.Has(v) && highlighted.Has(u) {
+				fmt.Fprintf(w, "  %q -- %q [color=red, penwidth=2];\n", name, g.names[u])
+			} else {
+				fmt.Fprintf(w, "  %q -- %q;\n", name, g.names[u])
+			}
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
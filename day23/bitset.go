@@ -0,0 +1,88 @@
+package main
+
+import "math/bits"
+
+// Bitset is a set of small non negative ints
+type Bitset []uint64
+
+func NewBitset(n int) Bitset {
+	return make(Bitset, (n+63)/64)
+}
+
+func (b Bitset) Set(i int) {
+	b[i/64] |= 1 << (i % 64)
+}
+
+func (b Bitset) Clear(i int) {
+	b[i/64] &^= 1 << (i % 64)
+}
+
+func (b Bitset) Has(i int) bool {
+	return b[i/64]&(1<<(i%64)) != 0
+}
+
+// And returns the intersection of b and other
+func (b Bitset) And(other Bitset) Bitset {
+	res := make(Bitset, len(b))
+	for i := range b {
+		res[i] = b[i] & other[i]
+	}
+	return res
+}
+
+// AndNot returns the elements of b not in other
+func (b Bitset) AndNot(other Bitset) Bitset {
+	res := make(Bitset, len(b))
+	for i := range b {
+		res[i] = b[i] &^ other[i]
+	}
+	return res
+}
+
+// Or returns the union of b and other
+func (b Bitset) Or(other Bitset) Bitset {
+	res := make(Bitset, len(b))
+	for i := range b {
+		res[i] = b[i] | other[i]
+	}
+	return res
+}
+
+// Count returns the number of elements in b
+func (b Bitset) Count() int {
+	count := 0
+	for _, word := range b {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// AndCount returns the number of elements in the intersection of b and other
+func (b Bitset) AndCount(other Bitset) int {
+	count := 0
+	for i := range b {
+		count += bits.OnesCount64(b[i] & other[i])
+	}
+	return count
+}
+
+func (b Bitset) IsEmpty() bool {
+	for _, word := range b {
+		if word != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Elements returns the elements of b in increasing order
+func (b Bitset) Elements() []int {
+	var res []int
+	for i, word := range b {
+		for word != 0 {
+			res = append(res, i*64+bits.TrailingZeros64(word))
+			word &= word - 1
+		}
+	}
+	return res
+}
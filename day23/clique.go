@@ -0,0 +1,242 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Graph is the network with its computers numbered in name order, with the
+// computers linked to each one as a bitset, for the clique algorithms
+type Graph struct {
+	names []string
+	adj   []Bitset
+}
+
+// degeneracyOrder returns the computers ordered by repeatedly removing the one
+// with the fewest links to the ones left, and the core number of each computer,
+// i.e. the largest k such that it belongs to a subgraph where all the computers
+// have at least k links
+func (g *Graph) degeneracyOrder() ([]int, []int) {
+	n := len(g.names)
+	degrees := make([]int, n)
+	for v := range n {
+		degrees[v] = g.adj[v].Count()
+	}
+	// buckets[d] holds the computers left with degree d, a computer can be in
+	// older buckets too and is skipped there when its degree does not match
+	buckets := make([][]int, n)
+	for v, d := range degrees {
+		buckets[d] = append(buckets[d], v)
+	}
+	removed := make([]bool, n)
+	order := make([]int, 0, n)
+	cores := make([]int, n)
+	core := 0
+	for d := 0; len(order) < n; {
+		if len(buckets[d]) == 0 {
+			d++
+			continue
+		}
+		v := buckets[d][len(buckets[d])-1]
+		buckets[d] = buckets[d][:len(buckets[d])-1]
+		if removed[v] || degrees[v] != d {
+			continue
+		}
+		removed[v] = true
+		order = append(order, v)
+		core = max(core, d)
+		cores[v] = core
+		for _, u := range g.adj[v].Elements() {
+			if !removed[u] {
+				degrees[u]--
+				buckets[degrees[u]] = append(buckets[degrees[u]], u)
+			}
+		}
+		// a neighbour can now be in a lower bucket
+		d = max(d-1, 0)
+	}
+	return order, cores
+}
+
+// maximalCliques calls report with each maximal clique of the graph, using the
+// Bron–Kerbosch algorithm with pivoting on the computers in degeneracy order
+func (g *Graph) maximalCliques(report func(clique []int)) {
+	n := len(g.names)
+	later := NewBitset(n)
+	for v := range n {
+		later.Set(v)
+	}
+	earlier := NewBitset(n)
+	order, _ := g.degeneracyOrder()
+	for _, v := range order {
+		later.Clear(v)
+		g.bronKerbosch([]int{v}, g.adj[v].And(later), g.adj[v].And(earlier), report)
+		earlier.Set(v)
+	}
+}
+
+// bronKerbosch reports all the maximal cliques containing the clique r, extended
+// with computers from p and none from x
+func (g *Graph) bronKerbosch(r []int, p, x Bitset, report func(clique []int)) {
+	if p.IsEmpty() {
+		if x.IsEmpty() {
+			report(r)
+		}
+		return
+	}
+	// the pivot is the computer linked to most of p, only the ones not linked to it
+	// need to be tried since the others are in a clique with it
+	pivot, pivotLinks := -1, -1
+	for _, u := range p.Or(x).Elements() {
+		if links := p.AndCount(g.adj[u]); links > pivotLinks {
+			pivot, pivotLinks = u, links
+		}
+	}
+	for _, v := range p.AndNot(g.adj[pivot]).Elements() {
+		g.bronKerbosch(append(r[:len(r):len(r)], v), p.And(g.adj[v]), x.And(g.adj[v]), report)
+		p.Clear(v)
+		x.Set(v)
+	}
+}
+
+// cliqueNames returns the names of the computers of a clique sorted and joined by
+// commas, which is the LAN party password for the largest clique
+func (g *Graph) cliqueNames(clique []int) string {
+	names := make([]string, len(clique))
+	for i, v := range clique {
+		names[i] = g.names[v]
+	}
+	slices.Sort(names)
+	return strings.Join(names, ",")
+}
+
+// largestClique returns the largest clique, the first one in alphabetical order of
+// the names if there are more
+func (g *Graph) largestClique() []int {
+	var best []int
+	bestNames := ""
+	g.maximalCliques(func(clique []int) {
+		if len(clique) < len(best) {
+			return
+		}
+		names := g.cliqueNames(clique)
+		if len(clique) > len(best) || names < bestNames {
+			best, bestNames = slices.Clone(clique), names
+		}
+	})
+	return best
+}
+
+// maximumClique returns the names of the largest clique
+func (g *Graph) maximumClique() string {
+	return g.cliqueNames(g.largestClique())
+}
+
+// cliquesOfSize returns the names of all the maximal cliques with at least
+// minSize computers, largest first and then in alphabetical order
+func (g *Graph) cliquesOfSize(minSize int) []string {
+	var cliques [][]int
+	g.maximalCliques(func(clique []int) {
+		if len(clique) >= minSize {
+			cliques = append(cliques, slices.Clone(clique))
+		}
+	})
+	slices.SortFunc(cliques, func(a, b []int) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(g.cliqueNames(a), g.cliqueNames(b))
+	})
+	res := make([]string, len(cliques))
+	for i, clique := range cliques {
+		res[i] = g.cliqueNames(clique)
+	}
+	return res
+}
+
+// NodeFilter selects computers by name
+type NodeFilter func(name string) bool
+
+func prefixFilter(prefix string) NodeFilter {
+	return func(name string) bool { return strings.HasPrefix(name, prefix) }
+}
+
+func regexpFilter(re *regexp.Regexp) NodeFilter {
+	return re.MatchString
+}
+
+func setFilter(names []string) NodeFilter {
+	set := map[string]bool{}
+	for _, name := range names {
+		set[name] = true
+	}
+	return func(name string) bool { return set[name] }
+}
+
+// all returns all the computers
+func (g *Graph) all() Bitset {
+	res := NewBitset(len(g.names))
+	for v := range g.names {
+		res.Set(v)
+	}
+	return res
+}
+
+// matching returns the computers selected by the filter
+func (g *Graph) matching(filter NodeFilter) Bitset {
+	res := NewBitset(len(g.names))
+	for v, name := range g.names {
+		if filter(name) {
+			res.Set(v)
+		}
+	}
+	return res
+}
+
+// kCliques calls report with each clique of k computers among the allowed ones,
+// each clique once with its computers in increasing order
+func (g *Graph) kCliques(k int, allowed Bitset, report func(clique []int)) {
+	var extend func(clique []int, candidates Bitset)
+	extend = func(clique []int, candidates Bitset) {
+		if len(clique) == k {
+			report(clique)
+			return
+		}
+		for _, v := range candidates.Elements() {
+			// only extend with larger computers so that each clique is found once
+			candidates.Clear(v)
+			extend(append(clique[:len(clique):len(clique)], v), candidates.And(g.adj[v]))
+		}
+	}
+	extend(nil, slices.Clone(allowed))
+}
+
+// countKCliques returns the number of cliques of k computers among the allowed ones,
+// counting the last computer of each clique with a popcount instead of listing it
+func (g *Graph) countKCliques(k int, allowed Bitset) int {
+	if k <= 0 {
+		return 0
+	}
+	var count func(size int, candidates Bitset) int
+	count = func(size int, candidates Bitset) int {
+		if size == k-1 {
+			return candidates.Count()
+		}
+		total := 0
+		for _, v := range candidates.Elements() {
+			candidates.Clear(v)
+			total += count(size+1, candidates.And(g.adj[v]))
+		}
+		return total
+	}
+	return count(0, slices.Clone(allowed))
+}
+
+// countFilteredKCliques returns the number of cliques of k computers where at least
+// one computer is selected by the filter, that is all the cliques minus the ones
+// made only of computers not selected
+func (g *Graph) countFilteredKCliques(k int, filter NodeFilter) int {
+	all := g.all()
+	return g.countKCliques(k, all) - g.countKCliques(k, all.AndNot(g.matching(filter)))
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"sort"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -15,48 +19,84 @@ import (
 // Find and count all the triples of computer ids that are linked together
 // where at least one starts with 't'
 
-type Graph map[string][]string // computer id -> list of linked computer ids
-
-func readInput() Graph {
+func readInput() *Graph {
 	file, err := os.Open("input/day23")
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	graph := Graph{}
-
-	for scanner.Scan() {
-		c1, c2 := scanner.Text()[:2], scanner.Text()[3:]
-		graph[c1] = append(graph[c1], c2)
-		graph[c2] = append(graph[c2], c1)
+	graph, err := parseGraph(file)
+	if err != nil {
+		log.Fatal(err)
 	}
-
 	return graph
 }
 
-func answer1() int {
-	graph := readInput()
-	triples := map[string]bool{}
-	for c1, linked := range graph {
-		for _, c2 := range linked {
-			for _, c3 := range graph[c2] {
-				if c3 != c1 {
-					if c1[0] == 't' || c2[0] == 't' || c3[0] == 't' {
-						for _, c1Wanted := range graph[c3] {
-							if c1Wanted == c1 {
-								set := []string{c1, c2, c3}
-								sort.Strings(set)
-								triples[set[0]+set[1]+set[2]] = true
-							}
-						}
-					}
-				}
-			}
+// parseGraph reads a list of links between two computers, one per line as "a-b",
+// where the names can have any length but cannot contain '-'. Links listed more
+// than once, in either direction, count once, and a computer cannot be linked to
+// itself.
+func parseGraph(r io.Reader) (*Graph, error) {
+	ids := map[string]int{} // computer name -> id in order of appearance
+	var names []string
+	intern := func(name string) int {
+		id, ok := ids[name]
+		if !ok {
+			id = len(names)
+			ids[name] = id
+			names = append(names, name)
+		}
+		return id
+	}
+
+	var links [][2]int
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		c1, c2, ok := strings.Cut(line, "-")
+		c1, c2 = strings.TrimSpace(c1), strings.TrimSpace(c2)
+		if !ok || c1 == "" || c2 == "" || strings.Contains(c2, "-") {
+			return nil, fmt.Errorf("line %d: invalid link %q", n, line)
+		}
+		if c1 == c2 {
+			return nil, fmt.Errorf("line %d: computer %s linked to itself", n, c1)
 		}
+		links = append(links, [2]int{intern(c1), intern(c2)})
 	}
-	return len(triples)
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// renumber the computers in name order, setting a link twice is a no-op so
+	// duplicated links go away in the bitsets
+	sorted := slices.Clone(names)
+	slices.Sort(sorted)
+	rank := make([]int, len(names))
+	for i, name := range sorted {
+		rank[ids[name]] = i
+	}
+	adj := make([]Bitset, len(names))
+	for i := range adj {
+		adj[i] = NewBitset(len(names))
+	}
+	for _, link := range links {
+		c1, c2 := rank[link[0]], rank[link[1]]
+		adj[c1].Set(c2)
+		adj[c2].Set(c1)
+	}
+	return &Graph{sorted, adj}, nil
+}
+
+// This is the count of the 3-cliques with at least one computer whose name starts
+// with 't', see countFilteredKCliques in clique.go for cliques of any size and
+// other filters.
+
+func answer1() int {
+	return readInput().countFilteredKCliques(3, prefixFilter("t"))
 }
 
 // -----------------------------------------------------------------------
@@ -65,70 +105,23 @@ func answer1() int {
 // now find the largest set of interconnected computer ids and return their names
 // sorted alphabetically and separated by commas
 
-func connectedToAll(pc string, network map[string]bool, graph Graph) bool {
-	for c := range network {
-		if c != pc && !contains(graph[c], pc) {
-			return false
-		}
-	}
-	return true
-}
-
-func contains(slice []string, item string) bool {
-	for _, element := range slice {
-		if element == item {
-			return true
-		}
-	}
-	return false
-}
+// Growing a network greedily from each computer is not guaranteed to find the
+// largest one, so we list the maximal cliques with Bron–Kerbosch (see clique.go)
+// and keep the largest.
 
-func answer2() int {
-	graph := readInput()
-	visited := map[string]bool{}
-	maxNetworkSize := 0
-	maxNetwork := map[string]bool{}
-	for pc := range graph {
-		network := map[string]bool{pc: true}
-		toVisit := []string{pc}
-		for len(toVisit) > 0 {
-			c := toVisit[len(toVisit)-1]
-			toVisit = toVisit[:len(toVisit)-1]
-			if visited[c] {
-				continue
-			}
-			visited[c] = true
-			for _, linked := range graph[c] {
-				if connectedToAll(linked, network, graph) {
-					network[linked] = true
-					toVisit = append(toVisit, linked)
-				}
-			}
-		}
-		if len(network) > maxNetworkSize {
-			maxNetworkSize = len(network)
-			maxNetwork = network
-		}
-	}
-	keys := []string{}
-	for k := range maxNetwork {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	fmt.Println(strings.Join(keys, ","))
-	return maxNetworkSize
+func answer2() string {
+	return readInput().maximumClique()
 }
 
 // -----------------------------------------------------------------------
 
-var correctAnswers = map[int]int{
-	1: 1368,
-	// dd,ig,il,im,kb,kr,pe,ti,tv,vr,we,xu,zi
-	2: 13,
+var correctAnswers = map[int]string{
+	1: "1368",
+	2: "dd,ig,il,im,kb,kr,pe,ti,tv,vr,we,xu,zi",
 }
 
-var answerFuncs = map[int]func() int{
-	1: answer1,
+var answerFuncs = map[int]func() string{
+	1: func() string { return strconv.Itoa(answer1()) },
 	2: answer2,
 }
 
@@ -141,15 +134,85 @@ func printAndTest(question int) {
 	println(answer)
 }
 
+var (
+	cliquesFlag = flag.Int("cliques", 0,
+		"list all the maximal cliques with at least this many computers instead of the answers")
+	kFlag = flag.Int("k", 0,
+		"count the cliques of k computers with at least one selected by the filter flags instead of the answers")
+	listFlag   = flag.Bool("list", false, "with -k, also list the cliques")
+	prefixFlag = flag.String("prefix", "", "select the computers whose name starts with this prefix")
+	regexpFlag = flag.String("regexp", "", "select the computers whose name matches this regular expression")
+	namesFlag  = flag.String("names", "", "select the computers in this comma separated list")
+	statsFlag  = flag.Bool("stats", false, "print statistics of the network instead of the answers")
+	dotFlag    = flag.String("dot", "",
+		"write the network in DOT format to this file, with the largest clique highlighted")
+)
+
+// filterFromFlags returns the filter set by the flags, all the computers if none
+func filterFromFlags() NodeFilter {
+	switch {
+	case *prefixFlag != "":
+		return prefixFilter(*prefixFlag)
+	case *regexpFlag != "":
+		re, err := regexp.Compile(*regexpFlag)
+		if err != nil {
+			log.Fatal("Invalid regexp ", *regexpFlag, ": ", err)
+		}
+		return regexpFilter(re)
+	case *namesFlag != "":
+		return setFilter(strings.Split(*namesFlag, ","))
+	}
+	return func(string) bool { return true }
+}
+
 func main() {
+	flag.Parse()
+	if *cliquesFlag > 0 {
+		for _, clique := range readInput().cliquesOfSize(*cliquesFlag) {
+			fmt.Println(clique)
+		}
+		return
+	}
+	if *statsFlag || *dotFlag != "" {
+		g := readInput()
+		if *statsFlag {
+			g.writeStats(os.Stdout)
+		}
+		if *dotFlag != "" {
+			file, err := os.Create(*dotFlag)
+			if err != nil {
+				log.Fatal(err)
+			}
+			g.writeDOT(file, g.largestClique())
+			if err := file.Close(); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return
+	}
+	if *kFlag > 0 {
+		g := readInput()
+		filter := filterFromFlags()
+		if *listFlag {
+			matching := g.matching(filter)
+			g.kCliques(*kFlag, g.all(), func(clique []int) {
+				if slices.ContainsFunc(clique, matching.Has) {
+					fmt.Println(g.cliqueNames(clique))
+				}
+			})
+		}
+		fmt.Println(g.countFilteredKCliques(*kFlag, filter))
+		return
+	}
+	args := flag.Args()
 	// if no argument, run all answers, otherwise only part 1 or 2
-	if len(os.Args) == 1 || os.Args[1] == "1" {
+	if len(args) == 0 || args[0] == "1" {
 		printAndTest(1)
 	}
-	if len(os.Args) == 1 || os.Args[1] == "2" {
+	if len(args) == 0 || args[0] == "2" {
 		printAndTest(2)
 	}
-	if len(os.Args) > 1 && os.Args[1] != "1" && os.Args[1] != "2" {
+	if len(args) > 0 && args[0] != "1" && args[0] != "2" {
 		println("Give 1 or 2 as argument, or no argument at all")
 	}
 }
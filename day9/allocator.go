@@ -0,0 +1,141 @@
+package main
+
+import (
+	"container/heap"
+	"slices"
+)
+
+// The compactions in main.go walk the linked list of blocks looking for free space,
+// from the start of the disk for each file, which is quadratic in the size of the
+// disk. Here we instead keep the free spans in a min-heap of their positions for
+// each length, so that the leftmost free span with at least some length is the
+// smallest of the tops of the heaps of that length and above. Moving a file into a
+// free span pops it and pushes what is left of it in the heap of its new length,
+// and since files only move left the space they free is never reused.
+
+// Span is a run of `length` blocks of file `id` on disk starting at `pos`, or of
+// empty blocks if `id` is `empty`
+type Span struct {
+	pos, length, id int
+}
+
+// spans returns the files and the free spans of the disk, in disk order
+func (d *Disk) spans() (files, free []Span) {
+	pos := 0
+	for b := d.first; b != nil; b = b.after {
+		if b.length > 0 {
+			if b.isEmpty() {
+				free = append(free, Span{pos, b.length, empty})
+			} else {
+				files = append(files, Span{pos, b.length, b.id})
+			}
+		}
+		pos += b.length
+	}
+	return files, free
+}
+
+// FreeSpans holds the positions of the free spans of each length
+type FreeSpans []PriorityQueue
+
+func newFreeSpans(free []Span) FreeSpans {
+	maxLength := 0
+	for _, s := range free {
+		maxLength = max(maxLength, s.length)
+	}
+	fs := make(FreeSpans, maxLength+1)
+	for _, s := range free {
+		fs[s.length] = append(fs[s.length], s.pos)
+	}
+	for length := range fs {
+		heap.Init(&fs[length])
+	}
+	return fs
+}
+
+// take removes the leftmost free span with at least minLength blocks before pos
+// and returns it, or false if there is none
+func (fs FreeSpans) take(minLength, before int) (Span, bool) {
+	best := Span{pos: before}
+	for length := max(minLength, 1); length < len(fs); length++ {
+		if pos, ok := fs[length].Peek(); ok && pos < best.pos {
+			best = Span{pos, length, empty}
+		}
+	}
+	if best.length == 0 {
+		return Span{}, false
+	}
+	heap.Pop(&fs[best.length])
+	return best, true
+}
+
+// put adds back the free span, if not empty
+func (fs FreeSpans) put(s Span) {
+	if s.length > 0 {
+		heap.Push(&fs[s.length], s.pos)
+	}
+}
+
+// fits returns true if the leftmost free span of the given length is before pos
+func (fs FreeSpans) fits(length, before int) bool {
+	pos, ok := fs[length].Peek()
+	return ok && pos < before
+}
+
+// takeLength removes the leftmost free span of the given length and returns it
+func (fs FreeSpans) takeLength(length int) Span {
+	return Span{heap.Pop(&fs[length]).(int), length, empty}
+}
+
+// compactSpans moves the files from the rightmost to the leftmost free span before
+// them. With fragmentation the blocks at the end of a file move one free span at a
+// time until there is none left before the file, otherwise whole files only move
+// to the leftmost free span that fits them. It returns the files after the moves.
+func compactSpans(files, free []Span, fragmentation bool) []Span {
+	if !fragmentation {
+		moved, _ := compactFiles(files, free, FirstFit{})
+		return moved
+	}
+	fs := newFreeSpans(free)
+	var moved []Span
+	for i := len(files) - 1; i >= 0; i-- {
+		file := files[i]
+		for file.length > 0 {
+			span, ok := fs.take(1, file.pos)
+			if !ok {
+				break
+			}
+			length := min(span.length, file.length)
+			moved = append(moved, Span{span.pos, length, file.id})
+			fs.put(Span{span.pos + length, span.length - length, empty})
+			file.length -= length
+		}
+		if file.length > 0 {
+			moved = append(moved, file)
+		}
+	}
+	return moved
+}
+
+// diskFromSpans returns the disk with the given files and empty blocks between them
+func diskFromSpans(files []Span) Disk {
+	files = slices.Clone(files)
+	slices.SortFunc(files, func(a, b Span) int { return a.pos - b.pos })
+	d := Disk{}
+	pos := 0
+	for _, f := range files {
+		if f.pos > pos {
+			d.append(&Blocks{id: empty, length: f.pos - pos})
+		}
+		d.append(&Blocks{id: f.id, length: f.length})
+		pos = f.pos + f.length
+	}
+	return d
+}
+
+// compactWithAllocator compacts the disk like compactWithFragmentation or
+// compactWithoutFragmentation, in O(n log n) for a disk of n spans
+func (d *Disk) compactWithAllocator(fragmentation bool) {
+	files, free := d.spans()
+	*d = diskFromSpans(compactSpans(files, free, fragmentation))
+}
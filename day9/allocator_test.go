@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// randomDiskMap returns a disk map with n files of 1 to 9 blocks and 0 to 9 empty
+// blocks between them, like the puzzle input
+func randomDiskMap(r *rand.Rand, n int) string {
+	var sb strings.Builder
+	for i := range n {
+		if i > 0 {
+			sb.WriteByte(byte('0' + r.Intn(10)))
+		}
+		sb.WriteByte(byte('1' + r.Intn(9)))
+	}
+	return sb.String()
+}
+
+func compactedChecksum(t testing.TB, diskMap string, compact func(d *Disk)) int {
+	d, err := parseDisk(diskMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact(&d)
+	return d.checkSum()
+}
+
+func TestAllocatorChecksums(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	diskMaps := []string{"2333133121414131402", "12345", "1", "90909"}
+	for range 200 {
+		diskMaps = append(diskMaps, randomDiskMap(r, 1+r.Intn(300)))
+	}
+	for _, diskMap := range diskMaps {
+		for _, fragmentation := range []bool{true, false} {
+			linkedList := (*Disk).compactWithoutFragmentation
+			if fragmentation {
+				linkedList = (*Disk).compactWithFragmentation
+			}
+			want := compactedChecksum(t, diskMap, linkedList)
+			got := compactedChecksum(t, diskMap, func(d *Disk) { d.compactWithAllocator(fragmentation) })
+			if got != want {
+				t.Fatalf("%s, fragmentation %v: got checksum %d, want %d", diskMap, fragmentation, got, want)
+			}
+		}
+	}
+	if got := compactedChecksum(t, "2333133121414131402", func(d *Disk) { d.compactWithAllocator(true) }); got != 1928 {
+		t.Errorf("example with fragmentation: got checksum %d, want 1928", got)
+	}
+	if got := compactedChecksum(t, "2333133121414131402", func(d *Disk) { d.compactWithAllocator(false) }); got != 2858 {
+		t.Errorf("example without fragmentation: got checksum %d, want 2858", got)
+	}
+}
+
+// the benchmarks compact a random disk map as long as the puzzle input
+func benchmarkCompaction(b *testing.B, compact func(d *Disk)) {
+	diskMap := randomDiskMap(rand.New(rand.NewSource(9)), 10000)
+	for range b.N {
+		b.StopTimer()
+		d, err := parseDisk(diskMap)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		compact(&d)
+	}
+}
+
+func BenchmarkLinkedListFragmentation(b *testing.B) {
+	benchmarkCompaction(b, (*Disk).compactWithFragmentation)
+}
+
+func BenchmarkAllocatorFragmentation(b *testing.B) {
+	benchmarkCompaction(b, func(d *Disk) { d.compactWithAllocator(true) })
+}
+
+func BenchmarkLinkedListWholeFiles(b *testing.B) {
+	benchmarkCompaction(b, (*Disk).compactWithoutFragmentation)
+}
+
+func BenchmarkAllocatorWholeFiles(b *testing.B) {
+	benchmarkCompaction(b, func(d *Disk) { d.compactWithAllocator(false) })
+}
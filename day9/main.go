@@ -1,10 +1,12 @@
 package main
 
 import (
-	"io"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // PART 1
@@ -219,32 +221,31 @@ func (d *Disk) compactWithFragmentation() {
 	}
 }
 
+func readDiskMap() string {
+	data, err := os.ReadFile(*inputFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
 func readInput() Disk {
-	file, err := os.Open("input/day9")
+	d, err := parseDisk(readDiskMap())
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer file.Close()
+	return d
+}
 
+// parseDisk returns the disk described by a disk map
+func parseDisk(diskMap string) (Disk, error) {
 	d := Disk{}
-
 	isSpace := false
-	for id := 0; ; isSpace = !isSpace {
-		b := make([]byte, 1)
-		_, err := file.Read(b)
-		if err == io.EOF {
-			break
+	for id, i := 0, 0; i < len(diskMap); i, isSpace = i+1, !isSpace {
+		if diskMap[i] < '0' || diskMap[i] > '9' {
+			return Disk{}, fmt.Errorf("invalid character in input: %v", diskMap[i])
 		}
-		if err != nil {
-			log.Fatalf("error reading file: %v", err)
-		}
-		if b[0] == '\n' {
-			break
-		}
-		if b[0] < '0' || b[0] > '9' {
-			log.Fatalf("invalid character in input: %v", b[0])
-		}
-		length := int(b[0] - '0')
+		length := int(diskMap[i] - '0')
 		if isSpace {
 			d.append(&Blocks{id: -1, length: length})
 		} else {
@@ -252,12 +253,15 @@ func readInput() Disk {
 			id++
 		}
 	}
-	return d
+	return d, nil
 }
 
+// The answers use the compactions of allocator.go, which give the same disks as
+// compactWithFragmentation and compactWithoutFragmentation, see allocator_test.go
+
 func answer1() int {
 	disk := readInput()
-	disk.compactWithFragmentation()
+	disk.compactWithAllocator(true)
 	return disk.checkSum()
 }
 
@@ -311,22 +315,27 @@ func (d *Disk) compactWithoutFragmentation() {
 
 func answer2() int {
 	disk := readInput()
-	disk.compactWithoutFragmentation()
+	disk.compactWithAllocator(false)
 	return disk.checkSum()
 }
 
-// printDisk prints the disk to stdout
+// printDisk prints the disk to stdout, with a digit per block as in the puzzle if
+// the file IDs have a single digit and with the IDs separated by spaces otherwise
 func printDisk(d *Disk) {
+	if d.maxID() >= 10 {
+		d.writeBlocks(os.Stdout)
+		return
+	}
 	for b := d.first; b != nil; b = b.after {
 		id := strconv.Itoa(b.id)
 		if b.isEmpty() {
 			id = "."
 		}
 		for i := 0; i < b.length; i++ {
-			print(id)
+			fmt.Print(id)
 		}
 	}
-	println()
+	fmt.Println()
 }
 
 // -----------------------------------------------------------------------
@@ -350,15 +359,73 @@ func printAndTest(question int) {
 	println(answer)
 }
 
+var (
+	inputFlag    = flag.String("input", "input/day9", "the input file, e.g. input/day9_test")
+	simulateFlag = flag.Bool("simulate", false,
+		"compare the compaction strategies of whole files instead of the answers")
+	largerThanFlag = flag.Int("larger-than", 4,
+		"with -simulate, the length of the largest files left in place by the larger-than strategy")
+	mapFlag  = flag.Bool("map", false, "print the disk map read back from the disk instead of the answers")
+	dumpFlag = flag.Bool("dump", false,
+		"print the blocks of the disk before and after moving whole files instead of the answers")
+	pngFlag = flag.String("png", "",
+		"render the disk before and after moving whole files to this PNG file instead of the answers")
+)
+
 func main() {
+	flag.Parse()
+	if *inputFlag != "input/day9" {
+		correctAnswers = map[int]int{}
+	}
+	if *simulateFlag {
+		d := readInput()
+		strategies := []Strategy{FirstFit{}, BestFit{}, WorstFit{},
+			LargerThan{*largerThanFlag, FirstFit{}}}
+		writeSimulations(os.Stdout, simulate(&d, strategies))
+		return
+	}
+	if *mapFlag {
+		d := readInput()
+		diskMap, err := d.diskMap()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if diskMap != readDiskMap() {
+			log.Fatal("the disk map read back differs from the input")
+		}
+		fmt.Println(diskMap)
+		return
+	}
+	if *dumpFlag || *pngFlag != "" {
+		before, after := readInput(), readInput()
+		after.compactWithAllocator(false)
+		if *dumpFlag {
+			printDisk(&before)
+			printDisk(&after)
+		}
+		if *pngFlag != "" {
+			file, err := os.Create(*pngFlag)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := writePNG(file, &before, &after); err != nil {
+				log.Fatal(err)
+			}
+			if err := file.Close(); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return
+	}
+	args := flag.Args()
 	// if no argument, run all answers, otherwise only part 1 or 2
-	if len(os.Args) == 1 || os.Args[1] == "1" {
+	if len(args) == 0 || args[0] == "1" {
 		printAndTest(1)
 	}
-	if len(os.Args) == 1 || os.Args[1] == "2" {
+	if len(args) == 0 || args[0] == "2" {
 		printAndTest(2)
 	}
-	if len(os.Args) > 1 && os.Args[1] != "1" && os.Args[1] != "2" {
+	if len(args) > 0 && args[0] != "1" && args[0] != "2" {
 		println("Give 1 or 2 as argument, or no argument at all")
 	}
 }
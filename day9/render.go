@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// diskMap returns the disk map of the disk, the format of the input. It only exists
+// for disks whose files have the IDs 0, 1, 2... in order with at most 9 blocks, and
+// at most 9 empty blocks between them, so not for most compacted disks.
+func (d *Disk) diskMap() (string, error) {
+	var sb strings.Builder
+	id, pos := 0, 0
+	isSpace := false
+	for b := d.first; b != nil; b = b.after {
+		if b.length > 9 {
+			return "", fmt.Errorf("%d blocks at position %d, more than a digit", b.length, pos)
+		}
+		if b.isEmpty() != isSpace {
+			// a file right after a file, with no empty blocks in between
+			if isSpace {
+				sb.WriteByte('0')
+			} else {
+				return "", fmt.Errorf("empty blocks at position %d not after a file", pos)
+			}
+		}
+		if !b.isEmpty() {
+			if b.id != id {
+				return "", fmt.Errorf("file %d at position %d, expected file %d", b.id, pos, id)
+			}
+			id++
+		}
+		sb.WriteByte(byte('0' + b.length))
+		isSpace = !b.isEmpty()
+		pos += b.length
+	}
+	return sb.String(), nil
+}
+
+// writeBlocks writes the ID of each block of the disk separated by spaces, with a
+// '.' for empty blocks
+func (d *Disk) writeBlocks(w io.Writer) {
+	sep := ""
+	for b := d.first; b != nil; b = b.after {
+		id := strconv.Itoa(b.id)
+		if b.isEmpty() {
+			id = "."
+		}
+		for i := 0; i < b.length; i++ {
+			fmt.Fprint(w, sep, id)
+			sep = " "
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// maxID returns the largest file ID on the disk, or empty if there are no files
+func (d *Disk) maxID() int {
+	res := empty
+	for b := d.first; b != nil; b = b.after {
+		res = max(res, b.id)
+	}
+	return res
+}
+
+// fileColor returns a colour for a file ID, spreading the hues of consecutive IDs
+// with the golden ratio so that neighbouring files stand out
+func fileColor(id int) color.RGBA {
+	if id == empty {
+		return color.RGBA{0, 0, 0, 255}
+	}
+	hue := math.Mod(float64(id)*0.618033988749895, 1) * 6
+	x := uint8(255 * (1 - math.Abs(math.Mod(hue, 2)-1)))
+	switch int(hue) {
+	case 0:
+		return color.RGBA{255, x, 0, 255}
+	case 1:
+		return color.RGBA{x, 255, 0, 255}
+	case 2:
+		return color.RGBA{0, 255, x, 255}
+	case 3:
+		return color.RGBA{0, x, 255, 255}
+	case 4:
+		return color.RGBA{x, 0, 255, 255}
+	}
+	return color.RGBA{255, 0, x, 255}
+}
+
+// renderWidth is the width in pixels, and blocks, of the rows of the rendered disks
+const renderWidth = 512
+
+// writePNG writes the disks as bands of pixels one above the other, separated by a
+// white line, with one pixel per block coloured by file ID and black for empty
+// blocks, wrapping the blocks in rows of renderWidth
+func writePNG(w io.Writer, disks ...*Disk) error {
+	rows := make([]int, len(disks))
+	height := len(disks) - 1
+	for i, d := range disks {
+		blocks := 0
+		for b := d.first; b != nil; b = b.after {
+			blocks += b.length
+		}
+		rows[i] = (blocks + renderWidth - 1) / renderWidth
+		height += rows[i]
+	}
+	img := image.NewRGBA(image.Rect(0, 0, renderWidth, max(height, 1)))
+	top := 0
+	for i, d := range disks {
+		if i > 0 {
+			for x := range renderWidth {
+				img.SetRGBA(x, top, color.RGBA{255, 255, 255, 255})
+			}
+			top++
+		}
+		pos := 0
+		for b := d.first; b != nil; b = b.after {
+			c := fileColor(b.id)
+			for range b.length {
+				img.SetRGBA(pos%renderWidth, top+pos/renderWidth, c)
+				pos++
+			}
+		}
+		top += rows[i]
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// A Strategy decides where whole files move when compacting the disk. Files are
+// considered from the rightmost and can only move to a free span before them.
+type Strategy interface {
+	// choose takes from the free spans the one to move the file to, which must fit
+	// it and be before it, or returns false to leave the file where it is
+	choose(file Span, fs FreeSpans) (Span, bool)
+	String() string
+}
+
+// FirstFit moves files to the leftmost free span that fits them, as in part 2
+type FirstFit struct{}
+
+func (FirstFit) choose(file Span, fs FreeSpans) (Span, bool) {
+	return fs.take(file.length, file.pos)
+}
+
+func (FirstFit) String() string { return "first-fit" }
+
+// BestFit moves files to the smallest free span that fits them, the leftmost one
+// if there are more
+type BestFit struct{}
+
+func (BestFit) choose(file Span, fs FreeSpans) (Span, bool) {
+	for length := max(file.length, 1); length < len(fs); length++ {
+		if fs.fits(length, file.pos) {
+			return fs.takeLength(length), true
+		}
+	}
+	return Span{}, false
+}
+
+func (BestFit) String() string { return "best-fit" }
+
+// WorstFit moves files to the largest free span, the leftmost one if there are more
+type WorstFit struct{}
+
+func (WorstFit) choose(file Span, fs FreeSpans) (Span, bool) {
+	for length := len(fs) - 1; length >= max(file.length, 1); length-- {
+		if fs.fits(length, file.pos) {
+			return fs.takeLength(length), true
+		}
+	}
+	return Span{}, false
+}
+
+func (WorstFit) String() string { return "worst-fit" }
+
+// LargerThan only moves the files longer than n blocks, with the given strategy
+type LargerThan struct {
+	n        int
+	strategy Strategy
+}
+
+func (s LargerThan) choose(file Span, fs FreeSpans) (Span, bool) {
+	if file.length <= s.n {
+		return Span{}, false
+	}
+	return s.strategy.choose(file, fs)
+}
+
+func (s LargerThan) String() string {
+	return fmt.Sprintf("%s, files > %d", s.strategy, s.n)
+}
+
+// compactFiles moves the whole files from the rightmost where the strategy chooses,
+// and returns the files after the moves and the number of files moved
+func compactFiles(files, free []Span, s Strategy) ([]Span, int) {
+	fs := newFreeSpans(free)
+	moved := make([]Span, 0, len(files))
+	moves := 0
+	for i := len(files) - 1; i >= 0; i-- {
+		file := files[i]
+		if file.length > 0 {
+			if span, ok := s.choose(file, fs); ok {
+				fs.put(Span{span.pos + file.length, span.length - file.length, empty})
+				file.pos = span.pos
+				moves++
+			}
+		}
+		moved = append(moved, file)
+	}
+	return moved, moves
+}
+
+// Simulation is the outcome of compacting a disk with a strategy
+type Simulation struct {
+	strategy    string
+	checksum    int
+	moves       int
+	freeSpans   int // free spans between files
+	largestFree int
+}
+
+// fragmentation returns the number of free spans between files and the length of
+// the largest one
+func (d *Disk) fragmentation() (int, int) {
+	count, largest := 0, 0
+	files, free := d.spans()
+	if len(files) == 0 {
+		return 0, 0
+	}
+	last := files[len(files)-1]
+	for _, s := range free {
+		if s.pos < last.pos {
+			count++
+			largest = max(largest, s.length)
+		}
+	}
+	return count, largest
+}
+
+// simulate compacts a copy of the disk with each strategy, the first simulation
+// being the disk as it is
+func simulate(d *Disk, strategies []Strategy) []Simulation {
+	freeSpans, largestFree := d.fragmentation()
+	res := []Simulation{{"none", d.checkSum(), 0, freeSpans, largestFree}}
+	files, free := d.spans()
+	for _, s := range strategies {
+		moved, moves := compactFiles(files, free, s)
+		compacted := diskFromSpans(moved)
+		freeSpans, largestFree := compacted.fragmentation()
+		res = append(res, Simulation{s.String(), compacted.checkSum(), moves, freeSpans, largestFree})
+	}
+	return res
+}
+
+func writeSimulations(w io.Writer, simulations []Simulation) {
+	fmt.Fprintf(w, "%-24s %16s %8s %10s %12s\n", "strategy", "checksum", "moves", "free spans", "largest free")
+	for _, s := range simulations {
+		fmt.Fprintf(w, "%-24s %16d %8d %10d %12d\n", s.strategy, s.checksum, s.moves, s.freeSpans, s.largestFree)
+	}
+}